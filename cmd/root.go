@@ -2,22 +2,44 @@ package cmd
 
 import (
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"github.com/vulcand/vulcand/engine"
 
+	"github.com/yieldr/vulcand-ingress/pkg/healthz"
 	"github.com/yieldr/vulcand-ingress/pkg/kubernetes"
+	corev1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/core/v1"
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
 	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/ingress"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/leaderelection"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/ownership"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/record"
+	"github.com/yieldr/vulcand-ingress/pkg/metrics"
 	"github.com/yieldr/vulcand-ingress/pkg/version"
 	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
 )
 
+// API groups from which ingresses can be watched.
+const (
+	ingressAPIExtensionsV1beta1 = "extensions/v1beta1"
+	ingressAPINetworkingV1      = "networking.k8s.io/v1"
+)
+
 var cmdRoot = &cobra.Command{
 	Use:   "vulcand-ingress",
 	Short: "vulcand ingress controller",
@@ -36,21 +58,207 @@ func runRoot(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
+	logger := logrus.New()
+
+	ocspPeriod, _ := cmd.Flags().GetDuration("ocsp-period")
+	ocspResponders, _ := cmd.Flags().GetStringSlice("ocsp-responder")
+	ocspSkipSignatureCheck, _ := cmd.Flags().GetBool("ocsp-skip-signature-check")
+	ocspStapling, _ := cmd.Flags().GetBool("ocsp-stapling")
+	ocsp := engine.OCSPSettings{
+		Enabled:            ocspStapling,
+		Responders:         ocspResponders,
+		SkipSignatureCheck: ocspSkipSignatureCheck,
+	}
+	if ocspPeriod != 0 {
+		ocsp.Period = ocspPeriod.String()
+	}
+	if err := vulcan.ValidateOCSPSettings(ocsp); err != nil {
+		fmt.Fprintf(os.Stderr, "%s", err)
+		os.Exit(1)
+	}
+
+	var tlsPolicy *vulcan.TLSPolicy
+	if filename, _ := cmd.Flags().GetString("tls-policy"); filename != "" {
+		tlsPolicy, err = vulcan.LoadTLSPolicy(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed loading TLS policy. %s", err)
+			os.Exit(1)
+		}
+	}
+
+	var listener *engine.Listener
+	if address, _ := cmd.Flags().GetString("https-listener"); address != "" {
+		l := vulcan.CreateHTTPSListener(address, tlsPolicy)
+		listener = &l
+	} else if tlsPolicy != nil {
+		fmt.Fprintf(os.Stderr, "--tls-policy requires --https-listener")
+		os.Exit(1)
+	}
+
 	vulcanAddr, _ := cmd.Flags().GetString("vulcand-addr")
-	vulcan := vulcan.New(vulcanAddr)
+	deletionDelay, _ := cmd.Flags().GetDuration("server-deletion-delay")
+	vulcan := vulcan.New(vulcanAddr, deletionDelay, logger)
+
+	namespaces, _ := cmd.Flags().GetStringSlice("namespace")
+
+	reconcilePeriod, _ := cmd.Flags().GetDuration("reconcile-period")
+	// Pods which no ingress routes to are only registered by the
+	// reconciliation.
+	if gate, _ := cmd.Flags().GetString("readiness-gate"); gate != "" && reconcilePeriod <= 0 {
+		fmt.Fprintf(os.Stderr, "--readiness-gate requires a positive --reconcile-period")
+		os.Exit(1)
+	}
+	sharedBackends, _ := cmd.Flags().GetBool("shared-backends")
+
+	defaultCertificate, _ := cmd.Flags().GetString("default-ssl-certificate")
+	wildcardCertificates, _ := cmd.Flags().GetStringSlice("wildcard-certificate")
+	certificates := wildcardCertificates
+	if defaultCertificate != "" {
+		certificates = append(certificates, defaultCertificate)
+	}
+	certificateNamespaces := sets.NewString()
+	for _, key := range certificates {
+		namespace, _, err := cache.SplitMetaNamespaceKey(key)
+		if err != nil || namespace == "" {
+			fmt.Fprintf(os.Stderr, "invalid certificate %q, expected <ns>/<name>", key)
+			os.Exit(1)
+		}
+		certificateNamespaces.Insert(namespace)
+	}
+	stuckTimeout, _ := cmd.Flags().GetDuration("stuck-sync-timeout")
+	shutdownGracePeriod, _ := cmd.Flags().GetDuration("shutdown-grace-period")
+
+	stateConfigMap, _ := cmd.Flags().GetString("state-configmap")
+	stateNamespace, stateName, err := cache.SplitMetaNamespaceKey(stateConfigMap)
+	if err != nil || stateNamespace == "" {
+		fmt.Fprintf(os.Stderr, "invalid state config map %q, expected <ns>/<name>", stateConfigMap)
+		os.Exit(1)
+	}
+
+	store := ownership.NewConfigMapStore(clientset.CoreV1(), stateNamespace, stateName)
+
+	metrics.RegisterWorkqueueProvider()
+	ownership.RegisterMetrics(store)
+
+	// Health checks are added to the mux once the controller is created.
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+
+	metricsAddr, _ := cmd.Flags().GetString("metrics-addr")
+	if metricsAddr != "" {
+		go func() {
+			if err := http.ListenAndServe(metricsAddr, mux); err != nil {
+				fmt.Fprintf(os.Stderr, "failed serving metrics. %s", err)
+				os.Exit(1)
+			}
+		}()
+	}
+
+	fieldSelectorFlag, _ := cmd.Flags().GetString("field-selector")
+	if cmd.Flags().Changed("selector") {
+		fieldSelectorFlag, _ = cmd.Flags().GetString("selector")
+	}
+	fieldSelector, err := fields.ParseSelector(fieldSelectorFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid field selector. %s", err)
+		os.Exit(1)
+	}
 
-	namespace, _ := cmd.Flags().GetString("namespace")
+	labelSelectorFlag, _ := cmd.Flags().GetString("label-selector")
+	labelSelector, err := labels.Parse(labelSelectorFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid label selector. %s", err)
+		os.Exit(1)
+	}
 
-	selector, _ := cmd.Flags().GetString("selector")
-	fields, err := fields.ParseSelector(selector)
+	namespaceSelectorFlag, _ := cmd.Flags().GetString("namespace-selector")
+	namespaceSelector, err := labels.Parse(namespaceSelectorFlag)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "invalid selector. %s", err)
+		fmt.Fprintf(os.Stderr, "invalid namespace selector. %s", err)
 		os.Exit(1)
 	}
 
-	ingressWatcher := cache.NewListWatchFromClient(clientset.ExtensionsV1beta1().RESTClient(), "ingresses", namespace, fields)
+	ingressAPI, _ := cmd.Flags().GetString("ingress-api")
+	if ingressAPI == "" {
+		servesV1, err := kubernetes.ServesNetworkingV1Ingress(clientset.Discovery())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed discovering ingress api. %s", err)
+			os.Exit(1)
+		}
+		ingressAPI = ingressAPIExtensionsV1beta1
+		if servesV1 {
+			ingressAPI = ingressAPINetworkingV1
+		}
+	}
+
+	ingressClass, _ := cmd.Flags().GetString("ingress-class")
+	withoutClass, _ := cmd.Flags().GetBool("watch-ingress-without-class")
+	classFilter := &ingress.ClassFilter{
+		Class:        ingressClass,
+		WithoutClass: withoutClass,
+	}
+
+	// Everything started by the controller stops once the stop channel is
+	// closed on SIGTERM or SIGINT.
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	go func() {
+		sig := <-signals
+		logger.WithField("signal", sig.String()).Info("Shutting down")
+		close(stop)
+	}()
+
+	namespaceFilter := &ingress.NamespaceFilter{
+		Namespaces: namespaces,
+		Selector:   namespaceSelector,
+	}
 
-	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	selection := &ingress.Selection{
+		Namespaces:    namespaceFilter,
+		LabelSelector: labelSelector,
+		FieldSelector: fieldSelector,
+	}
+
+	var ingressWatcher cache.ListerWatcher
+	var statusUpdater ingress.StatusUpdater
+	var networkingClient rest.Interface
+	switch ingressAPI {
+	case ingressAPIExtensionsV1beta1:
+		ingressWatcher = ingress.NewExtensionsV1beta1ListWatch(clientset.ExtensionsV1beta1().RESTClient(), selection)
+		statusUpdater = ingress.NewExtensionsV1beta1StatusUpdater(clientset.ExtensionsV1beta1())
+	case ingressAPINetworkingV1:
+		networkingClient, err = kubernetes.NewNetworkingV1(kubeconfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed creating networking client. %s", err)
+			os.Exit(1)
+		}
+		ingressWatcher = ingress.NewNetworkingV1ListWatch(networkingClient, selection)
+		statusUpdater = ingress.NewNetworkingV1StatusUpdater(networkingClient)
+	default:
+		fmt.Fprintf(os.Stderr, "invalid ingress api %q, expected %q or %q", ingressAPI, ingressAPIExtensionsV1beta1, ingressAPINetworkingV1)
+		os.Exit(1)
+	}
+
+	var publisher ingress.Publisher
+	publishService, _ := cmd.Flags().GetString("publish-service")
+	publishAddresses, _ := cmd.Flags().GetStringSlice("publish-address")
+	switch {
+	case publishService != "" && len(publishAddresses) > 0:
+		fmt.Fprintf(os.Stderr, "only one of --publish-service and --publish-address may be set")
+		os.Exit(1)
+	case publishService != "":
+		serviceNamespace, serviceName, err := cache.SplitMetaNamespaceKey(publishService)
+		if err != nil || serviceNamespace == "" {
+			fmt.Fprintf(os.Stderr, "invalid publish service %q, expected <ns>/<name>", publishService)
+			os.Exit(1)
+		}
+		publisher = ingress.NewServicePublisher(clientset.CoreV1(), serviceNamespace, serviceName)
+	case len(publishAddresses) > 0:
+		publisher = ingress.StaticPublisher(publishAddresses)
+	}
+
+	queue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), "ingress")
 
 	resourceHandler := cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
@@ -76,26 +284,379 @@ func runRoot(cmd *cobra.Command, args []string) {
 	indexer, informer := cache.NewIndexerInformer(
 		ingressWatcher,
 		&v1beta1.Ingress{},
-		0,
+		reconcilePeriod,
 		resourceHandler,
+		cache.Indexers{
+			ingress.ServiceIndex: ingress.IndexByService,
+			ingress.SecretIndex:  ingress.IndexBySecret,
+			cache.NamespaceIndex: cache.MetaNamespaceIndexFunc,
+		})
+
+	if !namespaceSelector.Empty() {
+		// Namespaces must be known before the first ingress is synced,
+		// otherwise ingresses in selected namespaces would be removed. When
+		// the labels of a namespace change, its ingresses are enqueued so
+		// they are added or removed accordingly.
+		namespacesWatcher := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "namespaces", "", fields.Everything())
+		namespacesStore, namespacesInformer := cache.NewInformer(
+			namespacesWatcher,
+			&v1.Namespace{},
+			0,
+			enqueueIngresses(queue, indexer, cache.NamespaceIndex))
+		go namespacesInformer.Run(stop)
+		if !cache.WaitForCacheSync(stop, namespacesInformer.HasSynced) {
+			fmt.Fprintf(os.Stderr, "failed syncing namespaces")
+			os.Exit(1)
+		}
+		namespaceFilter.NamespaceStore = namespacesStore
+	}
+
+	if ingressAPI == ingressAPINetworkingV1 && ingressClass != "" {
+		// IngressClass resources must be known before the first ingress is
+		// synced, otherwise ingresses of our class would be removed. When an
+		// IngressClass changes, every ingress is enqueued, so those whose
+		// class now points at the controller or elsewhere are added or
+		// removed accordingly.
+		classesWatcher := cache.NewListWatchFromClient(networkingClient, "ingressclasses", "", fields.Everything())
+		classesStore, classesInformer := cache.NewInformer(
+			classesWatcher,
+			&networkingv1.IngressClass{},
+			0,
+			enqueueAllIngresses(queue, indexer))
+		go classesInformer.Run(stop)
+		if !cache.WaitForCacheSync(stop, classesInformer.HasSynced) {
+			fmt.Fprintf(os.Stderr, "failed syncing ingress classes")
+			os.Exit(1)
+		}
+		classFilter.IngressClasses = classesStore
+	}
+
+	if publishService != "" {
+		// The addresses of the publish service change, for example once its
+		// load balancer is provisioned. Every ingress is enqueued then, so
+		// the addresses published to its status are kept up to date.
+		serviceNamespace, serviceName, _ := cache.SplitMetaNamespaceKey(publishService)
+		publishWatcher := cache.NewListWatchFromClient(clientset.CoreV1().RESTClient(), "services", serviceNamespace, fields.OneTermEqualSelector("metadata.name", serviceName))
+		_, publishInformer := cache.NewInformer(
+			publishWatcher,
+			&v1.Service{},
+			0,
+			enqueueAllIngresses(queue, indexer))
+		go publishInformer.Run(stop)
+	}
+
+	servicesWatcher := ingress.NewNamespacedListWatch(clientset.CoreV1().RESTClient(), "services", namespaceFilter)
+
+	// Ingresses refer to service ports by name or number, which are resolved
+	// through the service, and backend settings may be set as service
+	// annotations. Whenever a service changes we enqueue every ingress which
+	// routes traffic to that service.
+	servicesIndexer, servicesInformer := cache.NewIndexerInformer(
+		servicesWatcher,
+		&v1.Service{},
+		0,
+		enqueueIngresses(queue, indexer, ingress.ServiceIndex),
 		cache.Indexers{})
 
-	logger := logrus.New()
+	endpointsWatcher := ingress.NewNamespacedListWatch(clientset.CoreV1().RESTClient(), "endpoints", namespaceFilter)
 
-	controller := ingress.NewController(queue, indexer, informer, vulcan, logger)
+	// Endpoints share their key with the service they belong to, so whenever
+	// the pods behind a service change we enqueue every ingress which routes
+	// traffic to that service.
+	endpointsIndexer, endpointsInformer := cache.NewIndexerInformer(
+		endpointsWatcher,
+		&v1.Endpoints{},
+		0,
+		enqueueIngresses(queue, indexer, ingress.ServiceIndex),
+		cache.Indexers{
+			ingress.PodIndex: ingress.IndexByPod,
+		})
 
-	stop := make(chan struct{})
-	defer close(stop)
-	go controller.Run(1, stop)
+	// The controller's own certificates may live outside the watched
+	// namespaces, so their namespaces are watched for secrets as well.
+	secretsFilter := namespaceFilter
+	if len(namespaces) > 0 {
+		secretsFilter = &ingress.NamespaceFilter{
+			Namespaces: certificateNamespaces.Union(sets.NewString(namespaces...)).List(),
+		}
+	}
+
+	secretsWatcher := ingress.NewNamespacedListWatch(clientset.CoreV1().RESTClient(), "secrets", secretsFilter)
+
+	// Renewed certificates are pushed to vulcand by enqueueing every ingress
+	// which references the updated secret. Any ingress may use the
+	// controller's own certificates, so every ingress is enqueued when they
+	// change.
+	secretsIndexer, secretsInformer := cache.NewIndexerInformer(
+		secretsWatcher,
+		&v1.Secret{},
+		0,
+		enqueueSecretIngresses(queue, indexer, certificates),
+		cache.Indexers{})
+
+	var readinessGate *ingress.ReadinessGate
+	readinessGateCondition, _ := cmd.Flags().GetString("readiness-gate")
+	if readinessGateCondition != "" {
+		coreClient, err := kubernetes.NewCoreV1(kubeconfig)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed creating core client. %s", err)
+			os.Exit(1)
+		}
+
+		podsWatcher := ingress.NewNamespacedListWatch(coreClient, "pods", namespaceFilter)
+
+		// Pods are registered with vulcand as soon as their containers are
+		// ready, so every ingress routing to such a pod is enqueued.
+		podsIndexer, podsInformer := cache.NewIndexerInformer(
+			podsWatcher,
+			&corev1.Pod{},
+			0,
+			enqueuePodIngresses(queue, indexer, endpointsIndexer),
+			cache.Indexers{})
+
+		readinessGate = ingress.NewReadinessGate(readinessGateCondition, coreClient, podsIndexer, podsInformer)
+	}
+
+	recorder := record.NewRecorder(clientset.CoreV1(), "vulcand-ingress", logger)
+	go recorder.Run(stop)
+
+	controller := ingress.NewController(
+		queue,
+		indexer,
+		informer,
+		servicesIndexer,
+		servicesInformer,
+		endpointsIndexer,
+		endpointsInformer,
+		secretsIndexer,
+		secretsInformer,
+		vulcan,
+		store,
+		publisher,
+		statusUpdater,
+		recorder,
+		readinessGate,
+		logger,
+		namespaceFilter,
+		classFilter,
+		sharedBackends,
+		defaultCertificate,
+		wildcardCertificates,
+		ocsp,
+		listener,
+		reconcilePeriod,
+		stuckTimeout,
+		shutdownGracePeriod)
 
-	select {}
+	// Ownership records are loaded only right before the controller starts, so
+	// that a standby taking over sees the records of the previous leader.
+	run := func() {
+		if err := store.Load(); err != nil {
+			fmt.Fprintf(os.Stderr, "failed loading ownership records. %s", err)
+			os.Exit(1)
+		}
+		controller.Run(1, stop)
+	}
+
+	leaderElect, _ := cmd.Flags().GetBool("leader-elect")
+	if !leaderElect {
+		mux.Handle("/healthz", healthz.Handler(controller.Healthy))
+		mux.Handle("/readyz", healthz.Handler(controller.Ready))
+		run()
+		return
+	}
+
+	leaderConfigMap, _ := cmd.Flags().GetString("leader-election-configmap")
+	leaderNamespace, leaderName, err := cache.SplitMetaNamespaceKey(leaderConfigMap)
+	if err != nil || leaderNamespace == "" {
+		fmt.Fprintf(os.Stderr, "invalid leader election config map %q, expected <ns>/<name>", leaderConfigMap)
+		os.Exit(1)
+	}
+
+	identity, err := os.Hostname()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed getting leader election identity. %s", err)
+		os.Exit(1)
+	}
+
+	leaseDuration, _ := cmd.Flags().GetDuration("leader-election-lease-duration")
+	renewDeadline, _ := cmd.Flags().GetDuration("leader-election-renew-deadline")
+	retryPeriod, _ := cmd.Flags().GetDuration("leader-election-retry-period")
+
+	elector, err := leaderelection.NewLeaderElector(leaderelection.Config{
+		Lock:          leaderelection.NewConfigMapLock(clientset.CoreV1(), leaderNamespace, leaderName, identity),
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		// The controller takes up to the grace period to stop, and the lease
+		// is renewed until it did.
+		ShutdownGracePeriod: shutdownGracePeriod,
+		Callbacks: leaderelection.Callbacks{
+			OnStartedLeading: func(<-chan struct{}) {
+				run()
+			},
+			// Workers may still be writing to vulcand, so the only safe way
+			// to step down is to exit and let a standby take over.
+			OnStoppedLeading: func() {
+				logger.Fatal("Lost leadership, exiting")
+			},
+		},
+		Logger: logger,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid leader election configuration. %s", err)
+		os.Exit(1)
+	}
+
+	// Standbys don't run the controller, so they are ready as soon as they
+	// are waiting to take over.
+	mux.Handle("/healthz", healthz.Handler(controller.Healthy))
+	mux.Handle("/readyz", healthz.Handler(func() error {
+		if !elector.IsLeader() {
+			return nil
+		}
+		return controller.Ready()
+	}))
+
+	elector.Run(stop)
+}
+
+// enqueueIngresses returns an event handler which adds every ingress that
+// references the changed object through the given index to the queue.
+func enqueueIngresses(queue workqueue.Interface, indexer cache.Indexer, index string) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		keys, err := indexer.IndexKeys(index, key)
+		if err != nil {
+			return
+		}
+		for _, key := range keys {
+			queue.Add(key)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(old interface{}, new interface{}) {
+			enqueue(new)
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// enqueueAllIngresses returns an event handler which adds every ingress to the
+// queue.
+func enqueueAllIngresses(queue workqueue.Interface, indexer cache.Indexer) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		for _, key := range indexer.ListKeys() {
+			queue.Add(key)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(old interface{}, new interface{}) {
+			enqueue(new)
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// enqueueSecretIngresses returns an event handler which adds every ingress that
+// references the changed secret to the queue, or every ingress at all if the
+// secret is one of the given certificates.
+func enqueueSecretIngresses(queue workqueue.Interface, indexer cache.Indexer, certificates []string) cache.ResourceEventHandler {
+	global := sets.NewString(certificates...)
+	byIndex := enqueueIngresses(queue, indexer, ingress.SecretIndex)
+	enqueue := func(obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		if !global.Has(key) {
+			byIndex.OnAdd(obj)
+			return
+		}
+		for _, key := range indexer.ListKeys() {
+			queue.Add(key)
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(old interface{}, new interface{}) {
+			enqueue(new)
+		},
+		DeleteFunc: enqueue,
+	}
+}
+
+// enqueuePodIngresses returns an event handler which adds every ingress that
+// routes traffic to the changed pod to the queue.
+func enqueuePodIngresses(queue workqueue.Interface, indexer cache.Indexer, endpointsIndexer cache.Indexer) cache.ResourceEventHandler {
+	enqueue := func(obj interface{}) {
+		key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+		if err != nil {
+			return
+		}
+		endpoints, err := endpointsIndexer.IndexKeys(ingress.PodIndex, key)
+		if err != nil {
+			return
+		}
+		for _, endpoint := range endpoints {
+			keys, err := indexer.IndexKeys(ingress.ServiceIndex, endpoint)
+			if err != nil {
+				continue
+			}
+			for _, key := range keys {
+				queue.Add(key)
+			}
+		}
+	}
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc: enqueue,
+		UpdateFunc: func(old interface{}, new interface{}) {
+			enqueue(new)
+		},
+		DeleteFunc: enqueue,
+	}
 }
 
 func init() {
+	cmdRoot.Flags().String("ingress-class", "", "Ingress class served by the controller, matched against the kubernetes.io/ingress.class annotation and spec.ingressClassName. If empty every ingress is served.")
+	cmdRoot.Flags().Bool("watch-ingress-without-class", false, "Serve ingresses which don't specify a class. Only applies if --ingress-class is set.")
 	cmdRoot.Flags().String("kubeconfig", "", "Absolute path to the kubeconfig file. If empty an in-cluster configuration is assumed.")
-	cmdRoot.Flags().String("namespace", "", "Namespace in which to watch for resources.")
-	cmdRoot.Flags().String("selector", "", "Selector with which to match resources.")
+	cmdRoot.Flags().String("metrics-addr", ":10254", "Address on which to serve Prometheus metrics at /metrics and health checks at /healthz and /readyz. If empty nothing is served.")
+	cmdRoot.Flags().Duration("server-deletion-delay", 0, "Time servers removed from a backend because their pod is terminating or gone are kept in vulcand before they are deleted. Servers of pods which only turned unready are deleted right away. vulcand can't take a server out of rotation, so it keeps receiving new requests until then. Only use it with pods which keep serving for longer than the delay after they started terminating, for example with a preStop hook, and a termination grace period longer than that. Zero deletes them right away.")
+	cmdRoot.Flags().Duration("shutdown-grace-period", 10*time.Second, "Time syncs in progress are given to finish on shutdown. With --leader-elect it must be less than --leader-election-lease-duration.")
+	cmdRoot.Flags().Duration("stuck-sync-timeout", 10*time.Minute, "Time after which a worker processing the same ingress is considered stuck and the controller reported unhealthy. Zero disables the check.")
+	cmdRoot.Flags().StringSlice("namespace", nil, "Namespace in which to watch for resources. May be repeated to watch several namespaces. If empty every namespace is watched.")
+	cmdRoot.Flags().String("namespace-selector", "", "Label selector restricting the watched namespaces to those whose labels it matches.")
+	cmdRoot.Flags().String("label-selector", "", "Label selector with which to match ingresses.")
+	cmdRoot.Flags().String("field-selector", "", "Field selector with which to match ingresses.")
+	cmdRoot.Flags().String("selector", "", "Field selector with which to match ingresses.")
+	cmdRoot.Flags().MarkDeprecated("selector", "use --field-selector instead")
+	cmdRoot.Flags().Bool("leader-elect", false, "Elect a leader among the controller replicas, so that only the leader writes to vulcand.")
+	cmdRoot.Flags().String("leader-election-configmap", "default/vulcand-ingress-leader", "Config map in the format <ns>/<name> used as leader election lock.")
+	cmdRoot.Flags().Duration("leader-election-lease-duration", 15*time.Second, "Time standbys wait after the leader last renewed its lease before taking over.")
+	cmdRoot.Flags().Duration("leader-election-renew-deadline", 10*time.Second, "Time the leader keeps retrying to renew its lease before stepping down.")
+	cmdRoot.Flags().Duration("leader-election-retry-period", 2*time.Second, "Time between attempts to acquire or renew the lease.")
 	cmdRoot.Flags().String("vulcand-addr", "http://localhost:8182", "Vulcand API address.")
+	cmdRoot.Flags().String("state-configmap", "default/vulcand-ingress", "Config map in the format <ns>/<name> in which to record the vulcand objects created by the controller.")
+	cmdRoot.Flags().String("ingress-api", "", "API group version from which to watch ingresses, either extensions/v1beta1 or networking.k8s.io/v1. If empty the API served by the cluster is discovered.")
+	cmdRoot.Flags().String("publish-service", "", "Service in the format <ns>/<name> exposing vulcand, whose addresses are published to the status of every ingress served by the controller.")
+	cmdRoot.Flags().StringSlice("publish-address", nil, "IPs or hostnames published to the status of every ingress served by the controller, instead of the addresses of --publish-service.")
+	cmdRoot.Flags().String("readiness-gate", "", "Pod condition, for example vulcand.ingress/registered, set once a pod is registered with every vulcand backend routing to it. Pods listing it among their readiness gates only become ready once vulcand routes to them. Pods which no ingress routes to are registered by the reconciliation, so it requires a positive --reconcile-period. If empty no condition is managed.")
+	cmdRoot.Flags().String("default-ssl-certificate", "", "TLS secret in the format <ns>/<name> holding the certificate vulcand serves to clients whose SNI matches no host.")
+	cmdRoot.Flags().StringSlice("wildcard-certificate", nil, "TLS secret in the format <ns>/<name> holding a certificate, typically a wildcard certificate, given to every ingress host it covers which has no certificate of its own. May be repeated.")
+	cmdRoot.Flags().Bool("ocsp-stapling", false, "Staple OCSP responses to the certificates of every host, unless the ingress disables it with the ingress.kubernetes.io/ocsp annotation.")
+	cmdRoot.Flags().Duration("ocsp-period", 0, "Period at which OCSP responses are refreshed, unless the ingress sets the ingress.kubernetes.io/ocsp-period annotation. Zero uses the vulcand default of one hour.")
+	cmdRoot.Flags().StringSlice("ocsp-responder", nil, "OCSP responder URL to query instead of those named by the certificates, unless the ingress sets the ingress.kubernetes.io/ocsp-responders annotation. May be repeated.")
+	cmdRoot.Flags().Bool("ocsp-skip-signature-check", false, "Don't verify the signatures of OCSP responses, unless the ingress sets the ingress.kubernetes.io/ocsp-skip-signature-check annotation.")
+	cmdRoot.Flags().String("https-listener", "", "Address, for example 0.0.0.0:443, of an HTTPS listener managed by the controller, which enforces the TLS policy. If empty no listener is managed.")
+	cmdRoot.Flags().String("tls-policy", "", "Path to a YAML or JSON file holding the minVersion and approved cipherSuites enforced by the HTTPS listener. vulcand applies TLS settings per listener, so the policy holds for every host alike. Requires --https-listener.")
+	cmdRoot.Flags().Bool("shared-backends", false, "Share one vulcand backend between all ingresses routing to the same service port, instead of creating a backend per ingress. Shared backends are configured by service annotations only, and deleted along with the last ingress using them.")
+	cmdRoot.Flags().Duration("reconcile-period", 5*time.Minute, "Period at which all ingresses are resynced and orphaned vulcand objects are removed. Zero disables reconciliation.")
 }
 
 func Execute() {
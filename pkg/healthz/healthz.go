@@ -0,0 +1,24 @@
+// Package healthz serves health checks over HTTP.
+package healthz
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Checker reports an error if the component isn't healthy.
+type Checker func() error
+
+// Handler responds with 200 OK if all checks pass, and with 500 and the error
+// of the first failing check otherwise.
+func Handler(checks ...Checker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, check := range checks {
+			if err := check(); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
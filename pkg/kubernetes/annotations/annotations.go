@@ -8,12 +8,20 @@ import (
 )
 
 const (
-	// Backend related annotations
-	ReadTimeout         = "ingress.kubernetes.io/read-timeout"
-	DialTimeout         = "ingress.kubernetes.io/dial-timeout"
-	TLSHandshakeTimeout = "ingress.kubernetes.io/tls-handshake-timeout"
-	KeepAlive           = "ingress.kubernetes.io/keepalive"
-	MaxIdleConnsPerHost = "ingress.kubernetes.io/max-idle-connections-per-host"
+	// Backend related annotations. These may be set on the Service as well as
+	// the Ingress, in which case the Service takes precedence, since they are
+	// properties of the upstream service shared by every Ingress routing to
+	// it. Backends shared between ingresses only read them from the Service.
+	// See Lookup.
+	ReadTimeout               = "ingress.kubernetes.io/read-timeout"
+	DialTimeout               = "ingress.kubernetes.io/dial-timeout"
+	TLSHandshakeTimeout       = "ingress.kubernetes.io/tls-handshake-timeout"
+	KeepAlive                 = "ingress.kubernetes.io/keepalive"
+	MaxIdleConnsPerHost       = "ingress.kubernetes.io/max-idle-connections-per-host"
+	BackendProtocol           = "ingress.kubernetes.io/backend-protocol"
+	BackendCASecret           = "ingress.kubernetes.io/backend-ca-secret"
+	BackendClientSecret       = "ingress.kubernetes.io/backend-client-secret"
+	BackendInsecureSkipVerify = "ingress.kubernetes.io/backend-insecure-skip-verify"
 
 	// Frontend related annotations
 	TrustForwardHeader = "ingress.kubernetes.io/trust-forward-header"
@@ -22,6 +30,18 @@ const (
 	MaxMemBodyBytes    = "ingress.kubernetes.io/max-mem-body-bytes"
 	FailoverPredicate  = "ingress.kubernetes.io/failover-predicate"
 	Hostname           = "ingress.kubernetes.io/hostname"
+
+	// Host related annotations. They apply to every host of the Ingress and
+	// override the controller defaults.
+	OCSP                   = "ingress.kubernetes.io/ocsp"
+	OCSPPeriod             = "ingress.kubernetes.io/ocsp-period"
+	OCSPResponders         = "ingress.kubernetes.io/ocsp-responders"
+	OCSPSkipSignatureCheck = "ingress.kubernetes.io/ocsp-skip-signature-check"
+
+	// TLS annotations known from other ingress controllers. vulcand applies
+	// TLS settings per listener rather than per host, so they are rejected.
+	TLSMinVersion   = "ingress.kubernetes.io/tls-min-version"
+	TLSCipherSuites = "ingress.kubernetes.io/tls-cipher-suites"
 )
 
 var middlewareRegexp = regexp.MustCompile(`ingress.kubernetes.io/middleware\.(.*)`)
@@ -58,6 +78,17 @@ func GetBool(obj *v1beta1.Ingress, a string) bool {
 	return Bool(obj.Annotations[a])
 }
 
+// Lookup returns the value of the annotation from the first of the given
+// annotations which sets it, so they are given in order of precedence.
+func Lookup(a string, annotations ...map[string]string) string {
+	for _, annotations := range annotations {
+		if value, ok := annotations[a]; ok {
+			return String(value)
+		}
+	}
+	return ""
+}
+
 func GetMiddleware(obj *v1beta1.Ingress) map[string]string {
 	middleware := make(map[string]string)
 	for key, value := range obj.Annotations {
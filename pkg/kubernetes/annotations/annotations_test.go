@@ -52,3 +52,24 @@ func TestGetMiddleware(t *testing.T) {
 		})
 	}
 }
+
+func TestLookup(t *testing.T) {
+
+	service := map[string]string{ReadTimeout: "30s"}
+	ingress := map[string]string{ReadTimeout: "5s", DialTimeout: "7s"}
+
+	for annotation, expected := range map[string]string{
+		ReadTimeout: "30s",
+		DialTimeout: "7s",
+		KeepAlive:   "",
+	} {
+		actual := Lookup(annotation, service, ingress)
+		if actual != expected {
+			t.Errorf("Unexpected annotation %q value %q, expected %q", annotation, actual, expected)
+		}
+	}
+
+	if actual := Lookup(ReadTimeout, nil, ingress); actual != "5s" {
+		t.Errorf("Unexpected annotation value %q without service annotations", actual)
+	}
+}
@@ -0,0 +1,6 @@
+// Package v1 holds a subset of the core v1 Pod type.
+//
+// The vendored k8s.io/api predates pod readiness gates, so the fields of the
+// Pod the controller relies on to manage its readiness gate are defined here.
+// The types are wire compatible with the upstream definitions.
+package v1
@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ContainersReady means all containers of the pod are ready. Unlike PodReady
+// it doesn't take readiness gates into account.
+const ContainersReady v1.PodConditionType = "ContainersReady"
+
+// Pod is a collection of containers that can run on a host.
+type Pod struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PodSpec   `json:"spec,omitempty"`
+	Status PodStatus `json:"status,omitempty"`
+}
+
+// PodList is a list of Pods.
+type PodList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Pod `json:"items"`
+}
+
+// PodSpec is a description of a pod.
+type PodSpec struct {
+	ReadinessGates []PodReadinessGate `json:"readinessGates,omitempty"`
+}
+
+// PodReadinessGate contains the reference to a pod condition.
+type PodReadinessGate struct {
+	ConditionType v1.PodConditionType `json:"conditionType"`
+}
+
+// PodStatus represents information about the status of a pod.
+type PodStatus struct {
+	Conditions []v1.PodCondition `json:"conditions,omitempty"`
+}
+
+// Condition returns the condition of the given type, or nil if the pod
+// doesn't have it.
+func (p *Pod) Condition(conditionType v1.PodConditionType) *v1.PodCondition {
+	for i := range p.Status.Conditions {
+		if p.Status.Conditions[i].Type == conditionType {
+			return &p.Status.Conditions[i]
+		}
+	}
+	return nil
+}
+
+// HasReadinessGate reports whether the readiness of the pod depends on the
+// condition of the given type.
+func (p *Pod) HasReadinessGate(conditionType v1.PodConditionType) bool {
+	for _, gate := range p.Spec.ReadinessGates {
+		if gate.ConditionType == conditionType {
+			return true
+		}
+	}
+	return false
+}
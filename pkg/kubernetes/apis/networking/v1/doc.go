@@ -0,0 +1,6 @@
+// Package v1 holds the networking.k8s.io/v1 Ingress and IngressClass types.
+//
+// The vendored k8s.io/api predates the networking.k8s.io/v1 Ingress, so the
+// subset of the API the controller relies on is defined here. The types are
+// wire compatible with the upstream definitions.
+package v1
@@ -1,11 +1,17 @@
 package kubernetes
 
 import (
-	"log"
-
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
+
+	corev1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/core/v1"
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
 )
 
 func New(kubeconfig string) (*kubernetes.Clientset, error) {
@@ -16,6 +22,60 @@ func New(kubeconfig string) (*kubernetes.Clientset, error) {
 	return kubernetes.NewForConfig(c)
 }
 
+// NewNetworkingV1 creates a REST client for the networking.k8s.io/v1 API
+// group, which the vendored clientset does not know how to serve ingresses
+// from.
+func NewNetworkingV1(kubeconfig string) (rest.Interface, error) {
+	return newRESTClient(kubeconfig, "/apis", networkingv1.SchemeGroupVersion, networkingv1.AddToScheme)
+}
+
+// NewCoreV1 creates a REST client for the core v1 API group which decodes pods
+// including their readiness gates, which the vendored clientset does not know
+// about.
+func NewCoreV1(kubeconfig string) (rest.Interface, error) {
+	return newRESTClient(kubeconfig, "/api", corev1.SchemeGroupVersion, corev1.AddToScheme)
+}
+
+func newRESTClient(kubeconfig, apiPath string, groupVersion schema.GroupVersion, addToScheme func(*runtime.Scheme) error) (rest.Interface, error) {
+	c, err := getConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	scheme := runtime.NewScheme()
+	if err := addToScheme(scheme); err != nil {
+		return nil, err
+	}
+
+	config := *c
+	config.GroupVersion = &groupVersion
+	config.APIPath = apiPath
+	config.ContentType = runtime.ContentTypeJSON
+	config.NegotiatedSerializer = serializer.DirectCodecFactory{CodecFactory: serializer.NewCodecFactory(scheme)}
+	if config.UserAgent == "" {
+		config.UserAgent = rest.DefaultKubernetesUserAgent()
+	}
+	return rest.RESTClientFor(&config)
+}
+
+// ServesNetworkingV1Ingress reports whether the cluster serves ingresses from
+// the networking.k8s.io/v1 API group.
+func ServesNetworkingV1Ingress(client discovery.DiscoveryInterface) (bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(networkingv1.SchemeGroupVersion.String())
+	if errors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	for _, resource := range resources.APIResources {
+		if resource.Name == "ingresses" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func getConfig(kubeconfig string) (*rest.Config, error) {
 	if kubeconfig != "" {
 		apicfg, err := clientcmd.LoadFromFile(kubeconfig)
@@ -23,7 +83,6 @@ func getConfig(kubeconfig string) (*rest.Config, error) {
 			return nil, err
 		}
 		cfg := clientcmd.NewDefaultClientConfig(*apicfg, nil)
-		log.Fatal(cfg)
 		return cfg.ClientConfig()
 	}
 	return rest.InClusterConfig()
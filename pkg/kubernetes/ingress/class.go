@@ -0,0 +1,103 @@
+package ingress
+
+import (
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/client-go/tools/cache"
+
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
+)
+
+const (
+	// ClassAnnotation is the annotation with which an ingress selects the
+	// controller that should serve it.
+	ClassAnnotation = "kubernetes.io/ingress.class"
+
+	// DefaultClassAnnotation marks an IngressClass as the one assigned to
+	// ingresses which don't specify a class.
+	DefaultClassAnnotation = "ingressclass.kubernetes.io/is-default-class"
+
+	// ClassNameAnnotation carries the spec.ingressClassName of a
+	// networking.k8s.io/v1 ingress through its conversion to
+	// extensions/v1beta1, whose vendored types don't know the field. It is
+	// only set on the cached ingresses and never written back.
+	ClassNameAnnotation = "vulcand-ingress.yieldr.com/ingress-class-name"
+
+	// ControllerName identifies this controller in the spec.controller field
+	// of IngressClass resources.
+	ControllerName = "yieldr.com/vulcand-ingress"
+)
+
+// ClassFilter decides which ingresses are served by the controller, based on
+// their class.
+type ClassFilter struct {
+	// Class is the ingress class served by the controller. If empty, every
+	// ingress is served regardless of its class.
+	Class string
+
+	// WithoutClass claims ingresses which don't specify a class.
+	WithoutClass bool
+
+	// IngressClasses holds the IngressClass resources of the cluster. It may be
+	// nil if the cluster doesn't serve them.
+	IngressClasses cache.Store
+}
+
+// Matches reports whether an ingress with the given annotations and
+// spec.ingressClassName is served by the controller. The class annotation
+// takes precedence over the class name. Ingresses without a class are served
+// if the filter claims them, or if the default IngressClass of the cluster
+// belongs to the controller.
+func (f *ClassFilter) Matches(annotations map[string]string, className *string) bool {
+	if f == nil || f.Class == "" {
+		return true
+	}
+	if class, ok := annotations[ClassAnnotation]; ok {
+		return class == f.Class
+	}
+	if className != nil {
+		if *className == f.Class {
+			return true
+		}
+		class := f.ingressClass(*className)
+		return class != nil && class.Spec.Controller == ControllerName
+	}
+	return f.WithoutClass || f.ownsDefault()
+}
+
+// MatchesIngress reports whether the ingress is served by the controller. The
+// IngressClass resources may change at any time, so the filter is applied by
+// the controller rather than the list-watch.
+func (f *ClassFilter) MatchesIngress(ingress *v1beta1.Ingress) bool {
+	var className *string
+	if name, ok := ingress.Annotations[ClassNameAnnotation]; ok {
+		className = &name
+	}
+	return f.Matches(ingress.Annotations, className)
+}
+
+func (f *ClassFilter) ingressClass(name string) *networkingv1.IngressClass {
+	if f.IngressClasses == nil {
+		return nil
+	}
+	item, exists, err := f.IngressClasses.GetByKey(name)
+	if err != nil || !exists {
+		return nil
+	}
+	return item.(*networkingv1.IngressClass)
+}
+
+func (f *ClassFilter) ownsDefault() bool {
+	if f.IngressClasses == nil {
+		return false
+	}
+	for _, item := range f.IngressClasses.List() {
+		class := item.(*networkingv1.IngressClass)
+		if class.Annotations[DefaultClassAnnotation] != "true" {
+			continue
+		}
+		if class.Name == f.Class || class.Spec.Controller == ControllerName {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package ingress
+
+import (
+	"testing"
+
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
+)
+
+func TestClassFilter(t *testing.T) {
+	classes := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	classes.Add(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal"},
+		Spec:       networkingv1.IngressClassSpec{Controller: ControllerName},
+	})
+	classes.Add(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "nginx"},
+		Spec:       networkingv1.IngressClassSpec{Controller: "k8s.io/ingress-nginx"},
+	})
+
+	str := func(s string) *string { return &s }
+
+	for _, test := range []struct {
+		filter      *ClassFilter
+		annotations map[string]string
+		className   *string
+		expected    bool
+	}{
+		{nil, nil, nil, true},
+		{&ClassFilter{}, map[string]string{ClassAnnotation: "nginx"}, nil, true},
+		{&ClassFilter{Class: "vulcand"}, map[string]string{ClassAnnotation: "vulcand"}, nil, true},
+		{&ClassFilter{Class: "vulcand"}, map[string]string{ClassAnnotation: "nginx"}, nil, false},
+		{&ClassFilter{Class: "vulcand"}, map[string]string{ClassAnnotation: "nginx"}, str("vulcand"), false},
+		{&ClassFilter{Class: "vulcand"}, nil, str("vulcand"), true},
+		{&ClassFilter{Class: "vulcand"}, nil, str("nginx"), false},
+		{&ClassFilter{Class: "vulcand", IngressClasses: classes}, nil, str("internal"), true},
+		{&ClassFilter{Class: "vulcand", IngressClasses: classes}, nil, str("nginx"), false},
+		{&ClassFilter{Class: "vulcand"}, nil, nil, false},
+		{&ClassFilter{Class: "vulcand", WithoutClass: true}, nil, nil, true},
+		{&ClassFilter{Class: "vulcand", IngressClasses: classes}, nil, nil, false},
+	} {
+		if matches := test.filter.Matches(test.annotations, test.className); matches != test.expected {
+			t.Errorf("Unexpected match %t for filter %+v, annotations %v and class name %v", matches, test.filter, test.annotations, test.className)
+		}
+	}
+
+	classes.Update(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "internal",
+			Annotations: map[string]string{DefaultClassAnnotation: "true"},
+		},
+		Spec: networkingv1.IngressClassSpec{Controller: ControllerName},
+	})
+
+	filter := &ClassFilter{Class: "vulcand", IngressClasses: classes}
+	if !filter.Matches(nil, nil) {
+		t.Error("Expected ingress without class to match the default ingress class")
+	}
+}
+
+func TestClassFilterMatchesIngress(t *testing.T) {
+	classes := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	filter := &ClassFilter{Class: "vulcand", IngressClasses: classes}
+
+	ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{
+		Annotations: map[string]string{ClassNameAnnotation: "internal"},
+	}}
+	if filter.MatchesIngress(ingress) {
+		t.Error("Expected ingress of an unknown class not to match")
+	}
+
+	// The class created later admits the ingress without it being listed
+	// again.
+	classes.Add(&networkingv1.IngressClass{
+		ObjectMeta: metav1.ObjectMeta{Name: "internal"},
+		Spec:       networkingv1.IngressClassSpec{Controller: ControllerName},
+	})
+	if !filter.MatchesIngress(ingress) {
+		t.Error("Expected ingress to match once its class points at the controller")
+	}
+}
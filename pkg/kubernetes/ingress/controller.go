@@ -1,42 +1,122 @@
 package ingress
 
 import (
-	"errors"
 	"fmt"
 	"strings"
+	"sync"
 	"time"
 
+	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/util/workqueue"
 
 	"github.com/sirupsen/logrus"
+	"github.com/vulcand/vulcand/engine"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/ownership"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/record"
 	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
 )
 
 type Controller struct {
-	indexer  cache.Indexer
-	queue    workqueue.RateLimitingInterface
-	informer cache.Controller
-	vulcan   *vulcan.Client
-	logger   *logrus.Logger
+	indexer             cache.Indexer
+	queue               workqueue.RateLimitingInterface
+	informer            cache.Controller
+	servicesIndexer     cache.Indexer
+	servicesInformer    cache.Controller
+	endpointsIndexer    cache.Indexer
+	endpointsInformer   cache.Controller
+	secretsIndexer      cache.Indexer
+	secretsInformer     cache.Controller
+	vulcan              *vulcan.Client
+	ownership           ownership.Store
+	publisher           Publisher
+	status              StatusUpdater
+	recorder            record.EventRecorder
+	logger              *logrus.Logger
+	namespaces          *NamespaceFilter
+	class               *ClassFilter
+	sharedBackends      bool
+	defaultCertificate  string
+	certificates        []string
+	ocsp                engine.OCSPSettings
+	listener            *engine.Listener
+	reconcilePeriod     time.Duration
+	stuckTimeout        time.Duration
+	shutdownGracePeriod time.Duration
+
+	inflightMu sync.Mutex
+	inflight   map[interface{}]time.Time
+
+	readiness    *ReadinessGate
+	registeredMu sync.Mutex
+	registered   map[string]sets.String
+
+	certificateErrorsMu sync.Mutex
+	certificateErrors   map[string]string
 }
 
 func NewController(
 	queue workqueue.RateLimitingInterface,
 	indexer cache.Indexer,
 	informer cache.Controller,
+	servicesIndexer cache.Indexer,
+	servicesInformer cache.Controller,
+	endpointsIndexer cache.Indexer,
+	endpointsInformer cache.Controller,
+	secretsIndexer cache.Indexer,
+	secretsInformer cache.Controller,
 	vulcan *vulcan.Client,
-	logger *logrus.Logger) *Controller {
+	ownership ownership.Store,
+	publisher Publisher,
+	status StatusUpdater,
+	recorder record.EventRecorder,
+	readiness *ReadinessGate,
+	logger *logrus.Logger,
+	namespaces *NamespaceFilter,
+	class *ClassFilter,
+	sharedBackends bool,
+	defaultCertificate string,
+	certificates []string,
+	ocsp engine.OCSPSettings,
+	listener *engine.Listener,
+	reconcilePeriod time.Duration,
+	stuckTimeout time.Duration,
+	shutdownGracePeriod time.Duration) *Controller {
 
 	return &Controller{
-		informer: informer,
-		indexer:  indexer,
-		queue:    queue,
-		vulcan:   vulcan,
-		logger:   logger,
+		informer:            informer,
+		indexer:             indexer,
+		servicesIndexer:     servicesIndexer,
+		servicesInformer:    servicesInformer,
+		endpointsIndexer:    endpointsIndexer,
+		endpointsInformer:   endpointsInformer,
+		secretsIndexer:      secretsIndexer,
+		secretsInformer:     secretsInformer,
+		queue:               queue,
+		vulcan:              vulcan,
+		ownership:           ownership,
+		publisher:           publisher,
+		status:              status,
+		recorder:            recorder,
+		logger:              logger,
+		namespaces:          namespaces,
+		class:               class,
+		sharedBackends:      sharedBackends,
+		defaultCertificate:  defaultCertificate,
+		certificates:        certificates,
+		ocsp:                ocsp,
+		listener:            listener,
+		reconcilePeriod:     reconcilePeriod,
+		stuckTimeout:        stuckTimeout,
+		shutdownGracePeriod: shutdownGracePeriod,
+		inflight:            make(map[interface{}]time.Time),
+		readiness:           readiness,
+		registered:          make(map[string]sets.String),
+		certificateErrors:   make(map[string]string),
 	}
 }
 
@@ -46,10 +126,17 @@ func (c *Controller) processNextItem() bool {
 	if quit {
 		return false
 	}
+	// Items still queued when shutting down are left for the next leader or
+	// restart to pick up.
+	if c.queue.ShuttingDown() {
+		c.queue.Done(key)
+		return false
+	}
 	// Tell the queue that we are done with processing this key. This unblocks
 	// the key for other workers. This allows safe parallel processing because
 	// two pods with the same key are never processed in parallel.
 	defer c.queue.Done(key)
+	defer c.track(key)()
 
 	// Invoke the method containing the business logic.
 	err := c.apply(key.(string))
@@ -68,7 +155,7 @@ func (c *Controller) apply(key string) error {
 	if err != nil {
 		return err
 	}
-	if !exists {
+	if !exists || !c.selected(key) {
 		return c.remove(key)
 	}
 	return c.upsert(item, key)
@@ -79,96 +166,646 @@ func (c *Controller) remove(key string) error {
 	logger := c.logger.WithField("ingress", key)
 	logger.Info("Ingress has been removed")
 
-	split := strings.Split(key, "/")
-	if len(split) < 2 {
-		err := errors.New("Failed to split key %s in the format <ns>/<name>")
-		logger.WithError(err).Error("Failed deleting ingress")
+	forgetSync(key)
+	c.forgetRegistered(key)
+
+	// Clean up all the entries in vulcan that were created for this ingress
+	// resource, and nothing else.
+	records := c.ownership.List()
+	removed := ownership.ForIngress(records, key)
+
+	for _, record := range removed {
+
+		logger.Debug("Deleting vulcan objects")
+		if err := c.vulcan.Delete(ownership.Release(records, key, record.State())); err != nil {
+			logger.WithError(err).Error("Failed deleting vulcan objects")
+			return err
+		}
+
+		if err := c.ownership.Delete(record.UID); err != nil {
+			logger.WithError(err).Error("Failed deleting ownership record")
+			return err
+		}
+	}
+
+	// Other ingresses routing the hosts configured by this one may now fall
+	// back to a certificate of the controller.
+	c.requeueHosts(key, configuredHosts(removed))
+
+	// The ingress may still exist, for example if it moved to another ingress
+	// class, in which case the addresses published for it are no longer true.
+	if err := c.unpublish(key); err != nil {
+		logger.WithError(err).Error("Failed clearing ingress status")
+		return err
+	}
+
+	return nil
+}
+
+func (c *Controller) upsert(item interface{}, key string) error {
+	ingress := item.(*v1beta1.Ingress)
+
+	start := time.Now()
+	err := c.sync(ingress, key)
+	observeSync(key, start, err)
+	if err != nil {
+		c.recorder.Event(ingress, v1.EventTypeWarning, reasonOf(err), err.Error())
+		return err
+	}
+
+	if err := vulcan.CheckHostTLS(ingress); err != nil {
+		c.recorder.Event(ingress, v1.EventTypeWarning, ReasonInvalidTLS, err.Error())
+	}
+
+	if unknown := c.vulcan.UnknownMiddlewares(ingress); len(unknown) > 0 {
+		c.recorder.Eventf(ingress, v1.EventTypeWarning, ReasonUnknownMiddleware, "Ignoring unknown middlewares %s", strings.Join(unknown, ", "))
+	}
+
+	c.recorder.Event(ingress, v1.EventTypeNormal, ReasonSynced, "Synced to vulcand")
+	return nil
+}
+
+// sync pushes the vulcan objects of the ingress to vulcand. Errors carry the
+// reason of the event recorded for the failure.
+func (c *Controller) sync(ingress *v1beta1.Ingress, key string) error {
+	logger := c.logger.WithField("ingress", key)
+
+	desired, err := c.desired(ingress)
+	if err != nil {
+		logger.WithError(err).Error("Failed building vulcan objects")
 		return err
 	}
 
-	ns := split[0]
-	name := split[1]
+	existing, err := c.vulcan.List()
+	if err != nil {
+		logger.WithError(err).Error("Failed listing vulcan objects")
+		return withReason(ReasonVulcandError, err)
+	}
+
+	records := c.ownership.List()
+
+	// Refuse to touch anything in vulcan that this ingress doesn't own.
+	if err := ownership.Conflicts(records, key, desired, existing); err != nil {
+		logger.WithError(err).Error("Refusing to sync vulcan objects")
+		return withReason(ReasonConflict, err)
+	}
+
+	// Take note of what this ingress owns before syncing, so we can tell which
+	// objects are no longer desired afterwards. If the ingress was deleted and
+	// created again under the same name, the objects of the previous instance
+	// are taken over as well.
+	current := &vulcan.State{}
+	for _, record := range ownership.ForIngress(records, key) {
+		current.Merge(record.State())
+	}
 
-	// Clean up all the entries in vulcan that relate to this ingress
-	// resource.
-	logger.Debug("Deleting vulcan frontend")
-	if err := c.vulcan.DeleteFrontend(ns, name); err != nil {
-		logger.WithError(err).Error("Failed deleting vulcan frontend")
+	// Objects created by earlier versions of the controller under the old ID
+	// scheme were never recorded. They are taken over here, so they are moved
+	// to their new IDs by the sync below.
+	if legacy := ownership.Unowned(records, vulcan.CreateLegacyState(ingress), existing); !legacy.Empty() {
+		logger.WithFields(logrus.Fields{
+			"frontends":   len(legacy.Frontends),
+			"backends":    len(legacy.Backends),
+			"middlewares": len(legacy.Middlewares),
+		}).Info("Migrating legacy vulcan objects")
+		current.Merge(legacy)
+	}
+
+	// Objects are claimed before they are created, so that a crash half way
+	// through the sync can't leak them.
+	claimed := &vulcan.State{}
+	claimed.Merge(current)
+	claimed.Merge(desired)
+
+	uid := string(ingress.UID)
+
+	fallbacks := fallbackHosts(ingress, desired)
+
+	if err := c.ownership.Put(ownership.NewRecord(uid, key, claimed, fallbacks, records)); err != nil {
+		logger.WithError(err).Error("Failed recording vulcan objects")
 		return err
 	}
 
-	logger.Debug("Deleting vulcan backend")
-	if err := c.vulcan.DeleteBackend(ns, name); err != nil {
-		logger.WithError(err).Error("Failed deleting vulcan backend")
+	logger.Debug("Syncing vulcan objects")
+	if err := c.vulcan.Sync(desired); err != nil {
+		logger.WithError(err).Error("Failed syncing vulcan objects")
+		return withReason(ReasonVulcandError, err)
+	}
+
+	// Stale objects are deleted only once the desired objects are in place,
+	// so traffic is moved over before anything is taken away.
+	if stale := vulcan.Stale(current, desired); !stale.Empty() {
+		logger.Debug("Deleting stale vulcan objects")
+		if err := c.vulcan.Delete(ownership.Release(records, key, stale)); err != nil {
+			logger.WithError(err).Error("Failed deleting stale vulcan objects")
+			return withReason(ReasonVulcandError, err)
+		}
+		// A deleted frontend takes its route with it, even if a desired
+		// frontend shares the same route.
+		if len(stale.Frontends) > 0 {
+			if err := c.vulcan.SyncFrontends(desired); err != nil {
+				logger.WithError(err).Error("Failed syncing vulcan frontends")
+				return withReason(ReasonVulcandError, err)
+			}
+		}
+	}
+
+	record := ownership.NewRecord(uid, key, desired, fallbacks, records)
+	if err := c.ownership.Put(record); err != nil {
+		logger.WithError(err).Error("Failed recording vulcan objects")
 		return err
 	}
 
+	// Ingresses sharing a host whose configuration changed hands may have to
+	// drop or pick up a fallback certificate for it.
+	previous := configuredHosts(ownership.ForIngress(records, key))
+	configured := configuredHosts([]ownership.Record{record})
+	c.requeueHosts(key, previous.Difference(configured).Union(configured.Difference(previous)))
+
+	for _, record := range ownership.ForIngress(records, key) {
+		if record.UID == uid {
+			continue
+		}
+		if err := c.ownership.Delete(record.UID); err != nil {
+			logger.WithError(err).Error("Failed deleting ownership record")
+			return err
+		}
+	}
+
+	if err := c.register(key, desired); err != nil {
+		logger.WithError(err).Error("Failed updating pod readiness gates")
+		return withReason(ReasonReadinessGateFailed, err)
+	}
+
+	if err := c.publish(ingress); err != nil {
+		logger.WithError(err).Error("Failed updating ingress status")
+		return withReason(ReasonStatusFailed, err)
+	}
+
 	return nil
 }
 
-func (c *Controller) upsert(item interface{}, key string) error {
-	// Note that you also have to check the uid if you have a local
-	// controlled resource, which is dependent on the actual instance, to
-	// detect that a Ingress was recreated with the same name.
-	ingress := item.(*v1beta1.Ingress)
+// publish writes the load balancer addresses to the status of the ingress,
+// unless they are published already.
+func (c *Controller) publish(ingress *v1beta1.Ingress) error {
+	if c.publisher == nil {
+		return nil
+	}
+	addresses, err := c.publisher.Addresses()
+	if err != nil {
+		return err
+	}
+	if equalAddresses(ingress.Status.LoadBalancer.Ingress, addresses) {
+		return nil
+	}
+	return c.status.Update(ingress.Namespace, ingress.Name, PublishAddresses(addresses))
+}
+
+// unpublish removes the load balancer addresses from the status of the
+// ingress.
+func (c *Controller) unpublish(key string) error {
+	if c.publisher == nil {
+		return nil
+	}
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	addresses, err := c.publisher.Addresses()
+	if err != nil {
+		return err
+	}
+	return c.status.Update(namespace, name, UnpublishAddresses(addresses))
+}
+
+// desired builds the complete set of vulcan objects the ingress should
+// produce.
+func (c *Controller) desired(ingress *v1beta1.Ingress) (*vulcan.State, error) {
+
+	state := &vulcan.State{}
+
+	ocsp, err := vulcan.CreateOCSPSettings(ingress, c.ocsp)
+	if err != nil {
+		return nil, withReason(ReasonInvalidTLS, err)
+	}
+
+	// An ingress asking for TLS settings vulcand can't apply to its hosts
+	// alone isn't given any certificate, rather than serving them with weaker
+	// settings than asked for. The reason is recorded once the ingress synced.
+	skipTLS := vulcan.CheckHostTLS(ingress) != nil
+
+	// Hosts carrying the TLS certificates are synced before any frontend is
+	// created, so that routes are never served with a missing certificate.
+	for _, tls := range ingress.Spec.TLS {
+
+		if tls.SecretName == "" || skipTLS {
+			continue
+		}
+
+		keyPair, err := c.keyPair(ingress.Namespace, tls.SecretName)
+		if err != nil {
+			return nil, withReason(ReasonInvalidTLS, err)
+		}
+
+		for _, host := range tlsHosts(ingress, tls) {
+			state.AddHost(vulcan.CreateHost(host, keyPair, ocsp))
+		}
+	}
+
+	// Hosts without a certificate of their own are given the first of the
+	// controller's certificates covering them, typically a wildcard
+	// certificate, so it needn't be copied into every namespace. Hosts which
+	// another ingress already supplies a certificate for are left to it.
+	supplied := sets.NewString()
+	if len(c.certificates) > 0 {
+		key := ingress.Namespace + "/" + ingress.Name
+		for _, record := range c.ownership.List() {
+			if record.Ingress == key {
+				continue
+			}
+			for host := range record.HostSettings {
+				supplied.Insert(host)
+			}
+		}
+	}
+	for _, host := range Hosts(ingress) {
+		if skipTLS || state.HasHost(host) || supplied.Has(host) {
+			continue
+		}
+		if keyPair := c.coveringCertificate(host); keyPair != nil {
+			state.AddHost(vulcan.CreateHost(host, keyPair, ocsp))
+		}
+	}
+
+	// Clients whose SNI matches no host are served the default certificate.
+	// Every ingress claims the default host, so it stays in place as long as
+	// any ingress is served. Its settings can't depend on the ingress, so it
+	// is given the controller defaults.
+	if c.defaultCertificate != "" {
+		if keyPair := c.certificate(c.defaultCertificate); keyPair != nil {
+			state.AddHost(vulcan.CreateDefaultHost(keyPair, c.ocsp))
+		}
+	}
 
 	// First we sync the ingresses default backend. This is a fallback backend
 	// which should receive traffic if no other request matches.
 	if backend := ingress.Spec.Backend; backend != nil {
 
-		logger := c.logger.WithFields(logrus.Fields{
+		c.logger.WithFields(logrus.Fields{
 			"service": backend.ServiceName,
 			"port":    backend.ServicePort.String(),
-		})
-		logger.Debug("Syncing default ingress backend")
+		}).Debug("Syncing default ingress backend")
 
-		logger.Debug("Creating vulcan backend")
-		if err := c.vulcan.SyncBackend(ingress, backend); err != nil {
-			logger.WithError(err).Error("Failed creating vulcan backend")
-			return err
-		}
-
-		logger.Debug("Creating vulcan frontend")
-		if err := c.vulcan.SyncFrontend(ingress, backend, "", ""); err != nil {
-			logger.WithError(err).Error("Failed creating vulcan frontend")
-			return err
+		vulcanBackend, err := c.backend(ingress, backend)
+		if err != nil {
+			return nil, err
 		}
+		state.AddBackend(vulcanBackend)
+		state.AddFrontend(vulcan.CreateFrontend(ingress, vulcanBackend.Id, "", ""))
 	}
 
 	for _, rule := range ingress.Spec.Rules {
 
+		if rule.HTTP == nil {
+			continue
+		}
+
 		for _, path := range rule.HTTP.Paths {
 
-			logger := c.logger.WithFields(logrus.Fields{
+			c.logger.WithFields(logrus.Fields{
 				"host":    rule.Host,
 				"path":    path.Path,
 				"service": path.Backend.ServiceName,
 				"port":    path.Backend.ServicePort.String(),
-			})
+			}).Debug("Syncing ingress path")
 
-			logger.Debug("Creating vulcan backend")
-			if err := c.vulcan.SyncBackend(ingress, &path.Backend); err != nil {
-				logger.WithError(err).Error("Failed creating vulcan backend")
-				return err
+			vulcanBackend, err := c.backend(ingress, &path.Backend)
+			if err != nil {
+				return nil, err
 			}
+			state.AddBackend(vulcanBackend)
+			frontend := vulcan.CreateFrontend(ingress, vulcanBackend.Id, rule.Host, path.Path)
+			state.AddFrontend(frontend)
 
-			logger.Debug("Creating vulcan frontend")
-			if err := c.vulcan.SyncFrontend(ingress, &path.Backend, rule.Host, path.Path); err != nil {
-				logger.WithError(err).Error("Failed creating vulcan frontend")
-				return err
+			middlewares, err := c.vulcan.CreateMiddlewares(ingress, frontend.Key())
+			if err != nil {
+				return nil, withReason(ReasonInvalidMiddleware, err)
 			}
-
-			logger.Debug("Creating vulcan middleware")
-			if err := c.vulcan.SyncMiddleware(ingress, &path.Backend); err != nil {
-				logger.WithError(err).Error("Failed creating vulcan middleware")
-				return err
+			for _, middleware := range middlewares {
+				state.AddMiddleware(middleware)
 			}
 		}
 	}
 
+	return state, nil
+}
+
+// reconcile compares everything the controller created in vulcan with the
+// ingresses known to the indexer. Objects belonging to ingresses which no
+// longer exist, for example because they were deleted while the controller was
+// down, are garbage collected. Ingresses whose objects are missing from vulcan
+// are enqueued so they are pushed again.
+func (c *Controller) reconcile() {
+
+	c.logger.Debug("Reconciling vulcan objects")
+
+	c.syncListener()
+
+	owned := &vulcan.State{}
+	orphans := sets.NewString()
+
+	for _, record := range c.ownership.List() {
+		if !c.watching(record.Ingress) {
+			continue
+		}
+		if c.exists(record.Ingress) && c.selected(record.Ingress) {
+			owned.Merge(record.State())
+			continue
+		}
+		orphans.Insert(record.Ingress)
+	}
+
+	// Orphans are removed through the queue rather than right here, so that
+	// their removal can't race with the sync of an ingress created again under
+	// the same name. apply removes ingresses which are missing or unselected.
+	for _, key := range orphans.List() {
+		c.queue.Add(key)
+	}
+
+	current, err := c.vulcan.List()
+	if err != nil {
+		c.logger.WithError(err).Error("Failed listing vulcan objects")
+		return
+	}
+
+	requeued := 0
+
+	for _, item := range c.indexer.List() {
+		ingress := item.(*v1beta1.Ingress)
+
+		key, err := cache.MetaNamespaceKeyFunc(ingress)
+		if err != nil || !c.selected(key) {
+			continue
+		}
+
+		desired, err := c.desired(ingress)
+		if err != nil {
+			// The sync of this ingress fails as well and is retried through
+			// the queue, there is nothing more to do here.
+			continue
+		}
+
+		if missing := vulcan.Stale(desired, current); !missing.Empty() {
+			c.queue.Add(key)
+			requeued++
+		}
+	}
+
+	c.logger.WithFields(logrus.Fields{
+		"hosts":       len(owned.Hosts),
+		"frontends":   len(owned.Frontends),
+		"backends":    len(owned.Backends),
+		"middlewares": len(owned.Middlewares),
+		"orphans":     orphans.Len(),
+		"requeued":    requeued,
+	}).Info("Reconciled vulcan objects")
+
+	c.registerPending()
+}
+
+// watching reports whether the ingress lives in one of the namespaces this
+// controller is watching. Objects of ingresses in other namespaces may belong
+// to another instance of the controller and are left alone.
+func (c *Controller) watching(key string) bool {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	return err == nil && c.namespaces.Watches(namespace)
+}
+
+// selected reports whether the ingress lives in a namespace whose labels are
+// matched by the namespace selector, and is of a class served by the
+// controller. Ingresses which stopped matching either are removed from vulcan.
+func (c *Controller) selected(key string) bool {
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil || !c.namespaces.Matches(namespace) {
+		return false
+	}
+	if c.class == nil {
+		return true
+	}
+	item, exists, err := c.indexer.GetByKey(key)
+	if err != nil {
+		// Treat lookup errors as selected so that we never delete objects on
+		// a cache error.
+		return true
+	}
+	return exists && c.class.MatchesIngress(item.(*v1beta1.Ingress))
+}
+
+// exists reports whether the ingress is known to the indexer.
+func (c *Controller) exists(key string) bool {
+	_, exists, err := c.indexer.GetByKey(key)
+	// Treat lookup errors as existing so that we never delete objects on a
+	// cache error.
+	return exists || err != nil
+}
+
+// backend looks up the service referenced by the ingress backend and returns
+// the vulcand backend with its settings and servers. Backend settings set on
+// the service take precedence over those set on the ingress. When backends are
+// shared, every ingress routing to the service port uses the same backend,
+// which is configured by the service alone.
+func (c *Controller) backend(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend) (vulcan.Backend, error) {
+	var service *v1.Service
+	item, exists, err := c.servicesIndexer.GetByKey(ingress.Namespace + "/" + backend.ServiceName)
+	if err != nil {
+		return vulcan.Backend{}, err
+	}
+	if exists {
+		service = item.(*v1.Service)
+	}
+	id, settings := vulcan.CreateBackendID(ingress, backend), ingress
+	if c.sharedBackends {
+		id, settings = vulcan.CreateSharedBackendID(ingress.Namespace, backend, service), nil
+	}
+	if err := vulcan.CheckBackendTLS(settings, service); err != nil {
+		return vulcan.Backend{}, withReason(ReasonInvalidBackend, err)
+	}
+	servers, unready, err := c.servers(ingress.Namespace, settings, backend, service)
+	if err != nil {
+		return vulcan.Backend{}, err
+	}
+	return vulcan.Backend{
+		Backend: vulcan.CreateBackend(id, settings, service),
+		Servers: servers,
+		Unready: unready,
+		Shared:  c.sharedBackends,
+	}, nil
+}
+
+// servers resolves the port of the service referenced by the backend, and
+// returns a vulcand server for every ready pod address of the service
+// endpoints. ExternalName services are served by their external host. A
+// missing service or a service without endpoints results in a backend without
+// servers. The IDs of the servers of unready pods are returned as well. The
+// ingress, which may be nil, is only consulted for the backend protocol.
+func (c *Controller) servers(namespace string, ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend, service *v1.Service) ([]engine.Server, []string, error) {
+	if service == nil {
+		return nil, nil, nil
+	}
+	key := namespace + "/" + backend.ServiceName
+	scheme := vulcan.CreateScheme(ingress, service)
+	if service.Spec.Type == v1.ServiceTypeExternalName {
+		server, ok := vulcan.CreateExternalServer(service, backend.ServicePort, scheme)
+		if !ok {
+			return nil, nil, withReason(ReasonInvalidBackend, fmt.Errorf("service %s has no port %s", key, backend.ServicePort.String()))
+		}
+		return []engine.Server{server}, nil, nil
+	}
+	port, ok := vulcan.FindServicePort(service, backend.ServicePort)
+	if !ok {
+		return nil, nil, withReason(ReasonInvalidBackend, fmt.Errorf("service %s has no port %s", key, backend.ServicePort.String()))
+	}
+	item, exists, err := c.endpointsIndexer.GetByKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !exists {
+		return nil, nil, nil
+	}
+	endpoints, waiting := item.(*v1.Endpoints), c.waiting(namespace)
+	return vulcan.CreateServers(endpoints, port, scheme, waiting), vulcan.UnreadyServerIDs(endpoints, port, waiting), nil
+}
+
+// keyPair looks up the TLS secret and returns the vulcand key pair it holds.
+func (c *Controller) keyPair(namespace, name string) (*engine.KeyPair, error) {
+	item, exists, err := c.secretsIndexer.GetByKey(namespace + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, fmt.Errorf("secret %s/%s does not exist", namespace, name)
+	}
+	return vulcan.CreateKeyPair(item.(*v1.Secret))
+}
+
+// syncListener pushes the HTTPS listener managed by the controller, if any,
+// to vulcand, so that it enforces the TLS policy.
+func (c *Controller) syncListener() {
+	if c.listener == nil {
+		return
+	}
+	if err := c.vulcan.UpsertListener(*c.listener); err != nil {
+		c.logger.WithError(err).Error("Failed syncing vulcan listener")
+	}
+}
+
+// certificate looks up the TLS secret by its key in the format <ns>/<name>.
+// The controller's certificates are used by every ingress, so one which is
+// missing or invalid is skipped rather than failing all of them. The error is
+// logged once, until it changes.
+func (c *Controller) certificate(key string) *engine.KeyPair {
+	keyPair, err := c.loadCertificate(key)
+
+	c.certificateErrorsMu.Lock()
+	defer c.certificateErrorsMu.Unlock()
+
+	if err == nil {
+		delete(c.certificateErrors, key)
+		return keyPair
+	}
+	if c.certificateErrors[key] != err.Error() {
+		c.certificateErrors[key] = err.Error()
+		c.logger.WithError(err).WithField("certificate", key).Error("Skipping invalid certificate")
+	}
+	return nil
+}
+
+func (c *Controller) loadCertificate(key string) (*engine.KeyPair, error) {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return c.keyPair(namespace, name)
+}
+
+// coveringCertificate returns the key pair of the first of the controller's
+// certificates covering the host, or nil if none does.
+func (c *Controller) coveringCertificate(host string) *engine.KeyPair {
+	for _, key := range c.certificates {
+		keyPair := c.certificate(key)
+		if keyPair != nil && vulcan.Covers(keyPair, host) {
+			return keyPair
+		}
+	}
 	return nil
 }
 
+// fallbackHosts returns the hosts of the state which are given a certificate
+// of the controller rather than one of the ingress.
+func fallbackHosts(ingress *v1beta1.Ingress, state *vulcan.State) []string {
+	explicit := sets.NewString()
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			explicit.Insert(tlsHosts(ingress, tls)...)
+		}
+	}
+	var fallbacks []string
+	for _, host := range state.Hosts {
+		if host.Name != vulcan.DefaultHost && !explicit.Has(host.Name) {
+			fallbacks = append(fallbacks, host.Name)
+		}
+	}
+	return fallbacks
+}
+
+// tlsHosts returns the hosts the TLS entry of the ingress holds the certificate
+// of. An entry without hosts holds it for the hosts of the rules which no other
+// entry lists.
+func tlsHosts(ingress *v1beta1.Ingress, tls v1beta1.IngressTLS) []string {
+	if len(tls.Hosts) > 0 {
+		return tls.Hosts
+	}
+	listed := sets.NewString()
+	for _, tls := range ingress.Spec.TLS {
+		listed.Insert(tls.Hosts...)
+	}
+	var hosts []string
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" && !listed.Has(rule.Host) {
+			hosts = append(hosts, rule.Host)
+		}
+	}
+	return hosts
+}
+
+// configuredHosts returns the hosts whose settings the records configure.
+func configuredHosts(records []ownership.Record) sets.String {
+	hosts := sets.NewString()
+	for _, record := range records {
+		for host := range record.HostSettings {
+			hosts.Insert(host)
+		}
+	}
+	return hosts
+}
+
+// requeueHosts adds the other ingresses routing any of the hosts to the queue.
+func (c *Controller) requeueHosts(key string, hosts sets.String) {
+	if hosts.Len() == 0 {
+		return
+	}
+	for _, item := range c.indexer.List() {
+		ingress := item.(*v1beta1.Ingress)
+		other, err := cache.MetaNamespaceKeyFunc(ingress)
+		if err != nil || other == key || !c.selected(other) {
+			continue
+		}
+		if hosts.HasAny(Hosts(ingress)...) {
+			c.queue.Add(other)
+		}
+	}
+}
+
 // handleErr checks if an error happened and makes sure we will retry later.
 func (c *Controller) handleErr(err error, key interface{}) {
 	if err == nil {
@@ -207,20 +844,67 @@ func (c *Controller) Run(threadiness int, stopCh chan struct{}) {
 	c.logger.Info("Starting ingress controller")
 
 	go c.informer.Run(stopCh)
+	go c.servicesInformer.Run(stopCh)
+	go c.endpointsInformer.Run(stopCh)
+	go c.secretsInformer.Run(stopCh)
+
+	synced := []cache.InformerSynced{
+		c.informer.HasSynced,
+		c.servicesInformer.HasSynced,
+		c.endpointsInformer.HasSynced,
+		c.secretsInformer.HasSynced,
+	}
+	if c.readiness != nil {
+		go c.readiness.informer.Run(stopCh)
+		synced = append(synced, c.readiness.informer.HasSynced)
+	}
 
 	// Wait for all involved caches to be synced, before processing items from
 	// the queue is started.
-	if !cache.WaitForCacheSync(stopCh, c.informer.HasSynced) {
+	if !cache.WaitForCacheSync(stopCh, synced...) {
 		runtime.HandleError(fmt.Errorf("Timed out waiting for caches to sync"))
 		return
 	}
 
+	c.syncListener()
+
+	var workers sync.WaitGroup
 	for i := 0; i < threadiness; i++ {
-		go wait.Until(c.runWorker, time.Second, stopCh)
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(c.runWorker, time.Second, stopCh)
+		}()
+	}
+
+	if c.reconcilePeriod > 0 {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			wait.Until(c.reconcile, c.reconcilePeriod, stopCh)
+		}()
 	}
 
 	<-stopCh
 	c.logger.Info("Stopping ingress controller")
+
+	// No new items are taken from the queue once it is shut down, but syncs in
+	// progress are given the grace period to finish, so vulcand isn't left
+	// with half of an ingress.
+	c.queue.ShutDown()
+
+	done := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		c.logger.Info("Stopped ingress controller")
+	case <-time.After(c.shutdownGracePeriod):
+		c.logger.Warn("Timed out waiting for syncs in progress to finish")
+	}
 }
 
 func (c *Controller) runWorker() {
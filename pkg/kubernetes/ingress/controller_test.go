@@ -0,0 +1,361 @@
+package ingress
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vulcand/vulcand/engine"
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/ownership"
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+// fakeVulcand serves the hosts, listeners, frontends, backends, servers and
+// middlewares of the vulcand API. Objects are kept as posted, by the path of
+// their collection and their ID.
+type fakeVulcand struct {
+	mu      sync.Mutex
+	objects map[string]map[string]json.RawMessage
+}
+
+func newFakeVulcand() *fakeVulcand {
+	return &fakeVulcand{objects: make(map[string]map[string]json.RawMessage)}
+}
+
+func (f *fakeVulcand) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+
+	switch r.Method {
+	case "GET":
+		var ids []string
+		for id := range f.objects[path] {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		items := []json.RawMessage{}
+		for _, id := range ids {
+			items = append(items, f.objects[path][id])
+		}
+		name := strings.Title(path[strings.LastIndex(path, "/")+1:])
+		json.NewEncoder(w).Encode(map[string]interface{}{name: items})
+	case "POST":
+		var pack map[string]json.RawMessage
+		if err := json.NewDecoder(r.Body).Decode(&pack); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `{"message":%q}`, err.Error())
+			return
+		}
+		for kind, object := range pack {
+			if kind == "TTL" {
+				continue
+			}
+			var key struct{ Id, Name string }
+			json.Unmarshal(object, &key)
+			if key.Id == "" {
+				key.Id = key.Name
+			}
+			if f.objects[path] == nil {
+				f.objects[path] = make(map[string]json.RawMessage)
+			}
+			f.objects[path][key.Id] = object
+		}
+		w.Write([]byte("{}"))
+	case "DELETE":
+		i := strings.LastIndex(path, "/")
+		collection, id := path[:i], path[i+1:]
+		if _, ok := f.objects[collection][id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"message":"not found"}`))
+			return
+		}
+		delete(f.objects[collection], id)
+		// Nested collections, like the servers of a backend, go with it.
+		for nested := range f.objects {
+			if strings.HasPrefix(nested, path+"/") {
+				delete(f.objects, nested)
+			}
+		}
+		w.Write([]byte(`{"message":"deleted"}`))
+	}
+}
+
+// fakeRecorder remembers the reasons of the events recorded for each object.
+type fakeRecorder struct {
+	reasons map[string][]string
+}
+
+func (r *fakeRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	key, _ := cache.MetaNamespaceKeyFunc(object)
+	r.reasons[key] = append(r.reasons[key], reason)
+}
+
+func (r *fakeRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+func newTestController(t *testing.T, certificates []string, secrets ...*v1.Secret) (*Controller, *fakeRecorder) {
+	server := httptest.NewServer(newFakeVulcand())
+	t.Cleanup(server.Close)
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	secretsIndexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	for _, secret := range secrets {
+		secretsIndexer.Add(secret)
+	}
+
+	recorder := &fakeRecorder{reasons: make(map[string][]string)}
+
+	c := NewController(
+		workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+		cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		nil,
+		cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		nil,
+		cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		nil,
+		secretsIndexer,
+		nil,
+		vulcan.New(server.URL, 0, logger),
+		ownership.NewMemoryStore(),
+		nil,
+		nil,
+		recorder,
+		nil,
+		logger,
+		nil,
+		nil,
+		false,
+		"",
+		certificates,
+		engine.OCSPSettings{},
+		nil,
+		0,
+		0,
+		0)
+
+	return c, recorder
+}
+
+func newTestSecret(t *testing.T, namespace, name string, hosts ...string) *v1.Secret {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		DNSNames:     hosts,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Type:       v1.SecretTypeTLS,
+		Data: map[string][]byte{
+			v1.TLSCertKey:       pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+			v1.TLSPrivateKeyKey: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+		},
+	}
+}
+
+// newTestIngress returns an ingress routing the path of the host to the web
+// service, whose certificate is held by the secret, if any.
+func newTestIngress(name, host, path, secret string) *v1beta1.Ingress {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "namespace",
+			UID:       types.UID(name + "-uid"),
+		},
+		Spec: v1beta1.IngressSpec{
+			Rules: []v1beta1.IngressRule{{
+				Host: host,
+				IngressRuleValue: v1beta1.IngressRuleValue{
+					HTTP: &v1beta1.HTTPIngressRuleValue{
+						Paths: []v1beta1.HTTPIngressPath{{
+							Path: path,
+							Backend: v1beta1.IngressBackend{
+								ServiceName: "web",
+								ServicePort: intstr.FromInt(80),
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if secret != "" {
+		ingress.Spec.TLS = []v1beta1.IngressTLS{{Hosts: []string{host}, SecretName: secret}}
+	}
+	return ingress
+}
+
+// queued empties the queue of the controller and returns the keys it held.
+func queued(c *Controller) []string {
+	var keys []string
+	for c.queue.Len() > 0 {
+		key, _ := c.queue.Get()
+		c.queue.Done(key)
+		keys = append(keys, key.(string))
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hostCertificate returns the certificate vulcand serves for the host, or nil
+// if vulcand has no such host.
+func hostCertificate(t *testing.T, c *Controller, name string) []byte {
+	hosts, err := c.vulcan.GetHosts()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, host := range hosts {
+		if host.Name == name && host.Settings.KeyPair != nil {
+			return host.Settings.KeyPair.Cert
+		}
+	}
+	return nil
+}
+
+func TestControllerConflictingHosts(t *testing.T) {
+	foo := newTestSecret(t, "namespace", "foo-tls", "www.example.com")
+	bar := newTestSecret(t, "namespace", "bar-tls", "www.example.com")
+
+	c, recorder := newTestController(t, nil, foo, bar)
+
+	c.indexer.Add(newTestIngress("foo", "www.example.com", "/foo", "foo-tls"))
+	c.indexer.Add(newTestIngress("bar", "www.example.com", "/bar", "bar-tls"))
+
+	if err := c.apply("namespace/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.apply("namespace/bar"); reasonOf(err) != ReasonConflict {
+		t.Errorf("Expected a conflict syncing a host configured by another ingress, got %v", err)
+	}
+	if reasons := recorder.reasons["namespace/bar"]; !reflect.DeepEqual(reasons, []string{ReasonConflict}) {
+		t.Errorf("Unexpected events %v", reasons)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); !bytes.Equal(cert, foo.Data[v1.TLSCertKey]) {
+		t.Errorf("Expected the host to keep the certificate of its owner")
+	}
+}
+
+func TestControllerFallbackHandover(t *testing.T) {
+	foo := newTestSecret(t, "namespace", "foo-tls", "www.example.com")
+	wildcard := newTestSecret(t, "kube-system", "wildcard", "*.example.com")
+
+	c, _ := newTestController(t, []string{"kube-system/wildcard"}, foo, wildcard)
+
+	c.indexer.Add(newTestIngress("foo", "www.example.com", "/foo", "foo-tls"))
+	c.indexer.Add(newTestIngress("bar", "www.example.com", "/bar", ""))
+
+	if err := c.apply("namespace/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := queued(c); !reflect.DeepEqual(keys, []string{"namespace/bar"}) {
+		t.Errorf("Expected bar to be requeued once foo configured the host, got %v", keys)
+	}
+	if err := c.apply("namespace/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); !bytes.Equal(cert, foo.Data[v1.TLSCertKey]) {
+		t.Errorf("Expected the host to be served the certificate of foo")
+	}
+
+	// Once the owner of the host is deleted, the fallback certificate takes
+	// over.
+	c.indexer.Delete(newTestIngress("foo", "www.example.com", "/foo", "foo-tls"))
+	if err := c.apply("namespace/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := queued(c); !reflect.DeepEqual(keys, []string{"namespace/bar"}) {
+		t.Errorf("Expected bar to be requeued once foo was removed, got %v", keys)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); cert != nil {
+		t.Errorf("Expected the host to be removed with foo")
+	}
+	if err := c.apply("namespace/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); !bytes.Equal(cert, wildcard.Data[v1.TLSCertKey]) {
+		t.Errorf("Expected the host to fall back to the wildcard certificate")
+	}
+
+	// Once the owner is added again, it takes the host over from the
+	// fallback.
+	c.indexer.Add(newTestIngress("foo", "www.example.com", "/foo", "foo-tls"))
+	if err := c.apply("namespace/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if keys := queued(c); !reflect.DeepEqual(keys, []string{"namespace/bar"}) {
+		t.Errorf("Expected bar to be requeued once foo took the host over, got %v", keys)
+	}
+	if err := c.apply("namespace/bar"); err != nil {
+		t.Fatal(err)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); !bytes.Equal(cert, foo.Data[v1.TLSCertKey]) {
+		t.Errorf("Expected the host to be served the certificate of foo again")
+	}
+	for _, record := range c.ownership.List() {
+		if record.Ingress == "namespace/bar" && len(record.HostSettings) > 0 {
+			t.Errorf("Expected bar to drop the fallback host, got %v", record.HostSettings)
+		}
+	}
+}
+
+func TestControllerTLSWithoutHosts(t *testing.T) {
+	foo := newTestSecret(t, "namespace", "foo-tls", "www.example.com")
+
+	c, _ := newTestController(t, nil, foo)
+
+	// A TLS entry without hosts holds the certificate of the rule hosts.
+	ingress := newTestIngress("foo", "www.example.com", "/foo", "")
+	ingress.Spec.TLS = []v1beta1.IngressTLS{{SecretName: "foo-tls"}}
+	c.indexer.Add(ingress)
+
+	if err := c.apply("namespace/foo"); err != nil {
+		t.Fatal(err)
+	}
+	if cert := hostCertificate(t, c, "www.example.com"); !bytes.Equal(cert, foo.Data[v1.TLSCertKey]) {
+		t.Errorf("Expected the rule host to be served the certificate of the TLS entry")
+	}
+}
@@ -0,0 +1,39 @@
+package ingress
+
+// Reasons of the events recorded for ingresses.
+const (
+	ReasonSynced              = "Synced"
+	ReasonSyncFailed          = "SyncFailed"
+	ReasonVulcandError        = "VulcandError"
+	ReasonConflict            = "Conflict"
+	ReasonInvalidTLS          = "InvalidTLS"
+	ReasonInvalidBackend      = "InvalidBackend"
+	ReasonInvalidMiddleware   = "InvalidMiddleware"
+	ReasonUnknownMiddleware   = "UnknownMiddleware"
+	ReasonStatusFailed        = "StatusFailed"
+	ReasonReadinessGateFailed = "ReadinessGateFailed"
+)
+
+// reasonError attaches the reason of the event recorded for a failed sync to
+// the error.
+type reasonError struct {
+	reason string
+	err    error
+}
+
+func (e *reasonError) Error() string {
+	return e.err.Error()
+}
+
+func withReason(reason string, err error) error {
+	return &reasonError{reason: reason, err: err}
+}
+
+// reasonOf returns the event reason of the error, defaulting to
+// ReasonSyncFailed.
+func reasonOf(err error) string {
+	if e, ok := err.(*reasonError); ok {
+		return e.reason
+	}
+	return ReasonSyncFailed
+}
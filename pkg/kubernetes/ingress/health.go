@@ -0,0 +1,50 @@
+package ingress
+
+import (
+	"fmt"
+	"time"
+)
+
+// Ready reports an error unless the caches of the controller have synced and
+// vulcand is reachable.
+func (c *Controller) Ready() error {
+	if !c.informer.HasSynced() || !c.servicesInformer.HasSynced() || !c.endpointsInformer.HasSynced() || !c.secretsInformer.HasSynced() {
+		return fmt.Errorf("caches not synced")
+	}
+	if c.readiness != nil && !c.readiness.informer.HasSynced() {
+		return fmt.Errorf("caches not synced")
+	}
+	if err := c.vulcan.GetStatus(); err != nil {
+		return fmt.Errorf("vulcand unavailable: %s", err)
+	}
+	return nil
+}
+
+// Healthy reports an error if a worker has been stuck processing an ingress
+// for longer than the stuck timeout. A zero timeout disables the check.
+func (c *Controller) Healthy() error {
+	if c.stuckTimeout <= 0 {
+		return nil
+	}
+	c.inflightMu.Lock()
+	defer c.inflightMu.Unlock()
+	for key, start := range c.inflight {
+		if elapsed := time.Since(start); elapsed > c.stuckTimeout {
+			return fmt.Errorf("ingress %v has been processing for %s", key, elapsed)
+		}
+	}
+	return nil
+}
+
+// track takes note of the key being processed until the returned function is
+// called.
+func (c *Controller) track(key interface{}) func() {
+	c.inflightMu.Lock()
+	c.inflight[key] = time.Now()
+	c.inflightMu.Unlock()
+	return func() {
+		c.inflightMu.Lock()
+		delete(c.inflight, key)
+		c.inflightMu.Unlock()
+	}
+}
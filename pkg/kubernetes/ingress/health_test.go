@@ -0,0 +1,28 @@
+package ingress
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHealthy(t *testing.T) {
+	c := &Controller{
+		stuckTimeout: time.Minute,
+		inflight:     make(map[interface{}]time.Time),
+	}
+
+	done := c.track("namespace/ingress")
+	if err := c.Healthy(); err != nil {
+		t.Errorf("Unexpected error %s for a fresh sync", err)
+	}
+
+	c.inflight["namespace/ingress"] = time.Now().Add(-2 * time.Minute)
+	if err := c.Healthy(); err == nil {
+		t.Error("Expected a stuck sync to be reported")
+	}
+
+	done()
+	if err := c.Healthy(); err != nil {
+		t.Errorf("Unexpected error %s after the sync finished", err)
+	}
+}
@@ -0,0 +1,109 @@
+package ingress
+
+import (
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// ServiceIndex is the name of the ingress indexer index which maps a service
+// key in the format <ns>/<name> to the ingresses referencing it.
+const ServiceIndex = "service"
+
+// SecretIndex is the name of the ingress indexer index which maps a secret key
+// in the format <ns>/<name> to the ingresses referencing it for TLS.
+const SecretIndex = "secret"
+
+// PodIndex is the name of the endpoints indexer index which maps a pod key in
+// the format <ns>/<name> to the endpoints it is an address of.
+const PodIndex = "pod"
+
+// IndexByService is a cache.IndexFunc which indexes ingresses by the keys of
+// the services they route traffic to.
+func IndexByService(obj interface{}) ([]string, error) {
+	ingress, ok := obj.(*v1beta1.Ingress)
+	if !ok {
+		return nil, nil
+	}
+	return Services(ingress), nil
+}
+
+// Services returns the keys of all services referenced by the ingress in the
+// format <ns>/<name>.
+func Services(ingress *v1beta1.Ingress) []string {
+	services := sets.NewString()
+	if backend := ingress.Spec.Backend; backend != nil {
+		services.Insert(ingress.Namespace + "/" + backend.ServiceName)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for _, path := range rule.HTTP.Paths {
+			services.Insert(ingress.Namespace + "/" + path.Backend.ServiceName)
+		}
+	}
+	return services.List()
+}
+
+// IndexBySecret is a cache.IndexFunc which indexes ingresses by the keys of
+// the secrets they use for TLS termination.
+func IndexBySecret(obj interface{}) ([]string, error) {
+	ingress, ok := obj.(*v1beta1.Ingress)
+	if !ok {
+		return nil, nil
+	}
+	return Secrets(ingress), nil
+}
+
+// Secrets returns the keys of all secrets referenced by the ingress TLS
+// configuration in the format <ns>/<name>.
+func Secrets(ingress *v1beta1.Ingress) []string {
+	secrets := sets.NewString()
+	for _, tls := range ingress.Spec.TLS {
+		if tls.SecretName != "" {
+			secrets.Insert(ingress.Namespace + "/" + tls.SecretName)
+		}
+	}
+	return secrets.List()
+}
+
+// Hosts returns the hosts routed or terminated by the ingress.
+func Hosts(ingress *v1beta1.Ingress) []string {
+	hosts := sets.NewString()
+	for _, tls := range ingress.Spec.TLS {
+		hosts.Insert(tls.Hosts...)
+	}
+	for _, rule := range ingress.Spec.Rules {
+		if rule.Host != "" {
+			hosts.Insert(rule.Host)
+		}
+	}
+	return hosts.List()
+}
+
+// IndexByPod is a cache.IndexFunc which indexes endpoints by the keys of the
+// pods backing their addresses, whether they are ready or not.
+func IndexByPod(obj interface{}) ([]string, error) {
+	endpoints, ok := obj.(*v1.Endpoints)
+	if !ok {
+		return nil, nil
+	}
+	return Pods(endpoints), nil
+}
+
+// Pods returns the keys of all pods backing the addresses of the endpoints in
+// the format <ns>/<name>.
+func Pods(endpoints *v1.Endpoints) []string {
+	pods := sets.NewString()
+	for _, subset := range endpoints.Subsets {
+		for _, addresses := range [][]v1.EndpointAddress{subset.Addresses, subset.NotReadyAddresses} {
+			for _, address := range addresses {
+				if ref := address.TargetRef; ref != nil && ref.Kind == "Pod" {
+					pods.Insert(endpoints.Namespace + "/" + ref.Name)
+				}
+			}
+		}
+	}
+	return pods.List()
+}
@@ -0,0 +1,128 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestServices(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+		},
+		Spec: v1beta1.IngressSpec{
+			Backend: &v1beta1.IngressBackend{ServiceName: "default"},
+			Rules: []v1beta1.IngressRule{
+				{
+					Host: "example.com",
+					IngressRuleValue: v1beta1.IngressRuleValue{
+						HTTP: &v1beta1.HTTPIngressRuleValue{
+							Paths: []v1beta1.HTTPIngressPath{
+								{Path: "/foo", Backend: v1beta1.IngressBackend{ServiceName: "foo"}},
+								{Path: "/bar", Backend: v1beta1.IngressBackend{ServiceName: "foo"}},
+							},
+						},
+					},
+				},
+				{Host: "empty.example.com"},
+			},
+		},
+	}
+
+	expected := []string{"namespace/default", "namespace/foo"}
+
+	services, err := IndexByService(ingress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(services, expected) {
+		t.Errorf("Unexpected services %q, expected %q", services, expected)
+	}
+}
+
+func TestSecrets(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+		},
+		Spec: v1beta1.IngressSpec{
+			TLS: []v1beta1.IngressTLS{
+				{Hosts: []string{"example.com"}, SecretName: "example"},
+				{Hosts: []string{"www.example.com"}, SecretName: "example"},
+				{Hosts: []string{"foo.example.com"}},
+			},
+		},
+	}
+
+	expected := []string{"namespace/example"}
+
+	secrets, err := IndexBySecret(ingress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(secrets, expected) {
+		t.Errorf("Unexpected secrets %q, expected %q", secrets, expected)
+	}
+}
+
+func TestHosts(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		Spec: v1beta1.IngressSpec{
+			TLS: []v1beta1.IngressTLS{
+				{Hosts: []string{"example.com"}, SecretName: "example"},
+			},
+			Rules: []v1beta1.IngressRule{
+				{Host: "example.com"},
+				{Host: "api.example.com"},
+				{},
+			},
+		},
+	}
+
+	expected := []string{"api.example.com", "example.com"}
+
+	if hosts := Hosts(ingress); !reflect.DeepEqual(hosts, expected) {
+		t.Errorf("Unexpected hosts %q, expected %q", hosts, expected)
+	}
+}
+
+func TestPods(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "foo",
+			Namespace: "namespace",
+		},
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{
+					{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-1"}},
+					{IP: "10.0.0.2"},
+				},
+				NotReadyAddresses: []v1.EndpointAddress{
+					{IP: "10.0.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-3"}},
+				},
+			},
+			{
+				Addresses: []v1.EndpointAddress{
+					{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-1"}},
+				},
+			},
+		},
+	}
+
+	expected := []string{"namespace/foo-1", "namespace/foo-3"}
+
+	pods, err := IndexByPod(endpoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(pods, expected) {
+		t.Errorf("Unexpected pods %q, expected %q", pods, expected)
+	}
+}
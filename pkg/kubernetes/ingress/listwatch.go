@@ -0,0 +1,193 @@
+package ingress
+
+import (
+	"fmt"
+
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// Selection selects the ingresses served by the controller.
+type Selection struct {
+	// Namespaces selects the namespaces in which ingresses are watched. It may
+	// be nil to watch every namespace. Its selector isn't applied here.
+	Namespaces *NamespaceFilter
+	// LabelSelector and FieldSelector are passed to the API server. Either
+	// may be nil.
+	LabelSelector labels.Selector
+	FieldSelector fields.Selector
+}
+
+func (s *Selection) matches(ingress *v1beta1.Ingress) bool {
+	return s.Namespaces.Watches(ingress.Namespace)
+}
+
+// convertFunc converts a listed or watched object to an extensions/v1beta1
+// ingress.
+type convertFunc func(obj runtime.Object) (*v1beta1.Ingress, error)
+
+// NewExtensionsV1beta1ListWatch lists and watches the selected
+// extensions/v1beta1 ingresses.
+func NewExtensionsV1beta1ListWatch(c cache.Getter, selection *Selection) *cache.ListWatch {
+	lw := newSelectorListWatch(c, "ingresses", selection.Namespaces.WatchNamespace(), selection.LabelSelector, selection.FieldSelector)
+	return newListWatch(lw, selection, func(obj runtime.Object) (*v1beta1.Ingress, error) {
+		ingress, ok := obj.(*v1beta1.Ingress)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object type %T", obj)
+		}
+		// Listed objects come without type meta, which is needed to
+		// reference the ingress in events.
+		ingress.Kind = "Ingress"
+		ingress.APIVersion = v1beta1.SchemeGroupVersion.String()
+		return ingress, nil
+	})
+}
+
+// NewNamespacedListWatch lists and watches resources in the namespaces
+// watched by the filter.
+func NewNamespacedListWatch(c cache.Getter, resource string, namespaces *NamespaceFilter) *cache.ListWatch {
+	lw := newSelectorListWatch(c, resource, namespaces.WatchNamespace(), nil, nil)
+	if namespaces == nil {
+		return lw
+	}
+	matches := func(obj runtime.Object) bool {
+		accessor, err := meta.Accessor(obj)
+		return err == nil && namespaces.Watches(accessor.GetNamespace())
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			list, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+			items, err := meta.ExtractList(list)
+			if err != nil {
+				return nil, err
+			}
+			var matched []runtime.Object
+			for _, item := range items {
+				if matches(item) {
+					matched = append(matched, item)
+				}
+			}
+			if err := meta.SetList(list, matched); err != nil {
+				return nil, err
+			}
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				if event.Type == watch.Error {
+					return event, true
+				}
+				return event, matches(event.Object)
+			}), nil
+		},
+	}
+}
+
+// newSelectorListWatch is like cache.NewListWatchFromClient, but passes a
+// label selector to the API server as well.
+func newSelectorListWatch(c cache.Getter, resource, namespace string, labelSelector labels.Selector, fieldSelector fields.Selector) *cache.ListWatch {
+	if labelSelector == nil {
+		labelSelector = labels.Everything()
+	}
+	if fieldSelector == nil {
+		fieldSelector = fields.Everything()
+	}
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.LabelSelector = labelSelector.String()
+			options.FieldSelector = fieldSelector.String()
+			return c.Get().
+				Namespace(namespace).
+				Resource(resource).
+				VersionedParams(&options, metav1.ParameterCodec).
+				Do().
+				Get()
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.Watch = true
+			options.LabelSelector = labelSelector.String()
+			options.FieldSelector = fieldSelector.String()
+			return c.Get().
+				Namespace(namespace).
+				Resource(resource).
+				VersionedParams(&options, metav1.ParameterCodec).
+				Watch()
+		},
+	}
+}
+
+// newListWatch wraps lw, converting every ingress to its extensions/v1beta1
+// representation. Ingresses not matched by the selection are left out of
+// lists. When a watched ingress stops matching it is reported as deleted, so
+// that everything created for it is cleaned out of vulcand.
+func newListWatch(lw *cache.ListWatch, selection *Selection, convert convertFunc) *cache.ListWatch {
+	return &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			obj, err := lw.List(options)
+			if err != nil {
+				return nil, err
+			}
+			listMeta, err := meta.ListAccessor(obj)
+			if err != nil {
+				return nil, err
+			}
+			items, err := meta.ExtractList(obj)
+			if err != nil {
+				return nil, err
+			}
+			list := &v1beta1.IngressList{
+				ListMeta: metav1.ListMeta{
+					SelfLink:        listMeta.GetSelfLink(),
+					ResourceVersion: listMeta.GetResourceVersion(),
+					Continue:        listMeta.GetContinue(),
+				},
+			}
+			for _, item := range items {
+				ingress, err := convert(item)
+				if err != nil {
+					return nil, err
+				}
+				if selection.matches(ingress) {
+					list.Items = append(list.Items, *ingress)
+				}
+			}
+			return list, nil
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			w, err := lw.Watch(options)
+			if err != nil {
+				return nil, err
+			}
+			return watch.Filter(w, func(event watch.Event) (watch.Event, bool) {
+				if event.Type == watch.Error {
+					return event, true
+				}
+				ingress, err := convert(event.Object)
+				if err != nil {
+					return event, true
+				}
+				event.Object = ingress
+				if event.Type != watch.Deleted && !selection.matches(ingress) {
+					if event.Type == watch.Added {
+						return event, false
+					}
+					event.Type = watch.Deleted
+				}
+				return event, true
+			}), nil
+		},
+	}
+}
@@ -0,0 +1,41 @@
+package ingress
+
+import (
+	"time"
+
+	"github.com/yieldr/vulcand-ingress/pkg/metrics"
+)
+
+var (
+	syncs = metrics.NewCounterVec(
+		"vulcand_ingress_syncs_total",
+		"Total number of ingress syncs by ingress and result.",
+		"ingress", "result")
+	syncDuration = metrics.NewHistogramVec(
+		"vulcand_ingress_sync_duration_seconds",
+		"Time it takes to sync an ingress to vulcand.",
+		metrics.DefBuckets,
+		"ingress")
+)
+
+func init() {
+	metrics.MustRegister(syncs, syncDuration)
+}
+
+// observeSync records the outcome of a sync of the ingress which started at
+// start.
+func observeSync(key string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	syncs.With(key, result).Inc()
+	syncDuration.With(key).Observe(time.Since(start).Seconds())
+}
+
+// forgetSync removes the sync metrics of a removed ingress.
+func forgetSync(key string) {
+	syncs.Delete(key, "success")
+	syncs.Delete(key, "error")
+	syncDuration.Delete(key)
+}
@@ -0,0 +1,66 @@
+package ingress
+
+import (
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+// NamespaceFilter decides which namespaces are watched by the controller.
+type NamespaceFilter struct {
+	// Namespaces lists the watched namespaces. If empty, every namespace is
+	// watched.
+	Namespaces []string
+
+	// Selector additionally restricts the watched namespaces to those whose
+	// labels it matches. It may be nil. Namespace labels can change at any
+	// time, so the selector is applied by the controller rather than the
+	// list-watch.
+	Selector labels.Selector
+
+	// NamespaceStore holds the namespaces of the cluster. It is required if
+	// Selector is set.
+	NamespaceStore cache.Store
+}
+
+// Watches reports whether the namespace is one of the watched namespaces,
+// regardless of its labels.
+func (f *NamespaceFilter) Watches(namespace string) bool {
+	return f == nil || len(f.Namespaces) == 0 || containsString(f.Namespaces, namespace)
+}
+
+// Matches reports whether the namespace is watched and its labels are matched
+// by the selector.
+func (f *NamespaceFilter) Matches(namespace string) bool {
+	if !f.Watches(namespace) {
+		return false
+	}
+	if f == nil || f.Selector == nil || f.Selector.Empty() {
+		return true
+	}
+	item, exists, err := f.NamespaceStore.GetByKey(namespace)
+	if err != nil || !exists {
+		return false
+	}
+	return f.Selector.Matches(labels.Set(item.(*v1.Namespace).Labels))
+}
+
+// WatchNamespace returns the namespace to list and watch resources in. Unless
+// a single namespace is watched, resources are watched cluster wide and
+// filtered by Watches.
+func (f *NamespaceFilter) WatchNamespace() string {
+	if f == nil || len(f.Namespaces) != 1 {
+		return metav1.NamespaceAll
+	}
+	return f.Namespaces[0]
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
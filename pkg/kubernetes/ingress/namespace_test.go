@@ -0,0 +1,61 @@
+package ingress
+
+import (
+	"testing"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestNamespaceFilter(t *testing.T) {
+	namespaces := cache.NewStore(cache.MetaNamespaceKeyFunc)
+	namespaces.Add(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Labels: map[string]string{"team": "web"}},
+	})
+	namespaces.Add(&v1.Namespace{
+		ObjectMeta: metav1.ObjectMeta{Name: "bar", Labels: map[string]string{"team": "data"}},
+	})
+
+	selector := labels.SelectorFromSet(labels.Set{"team": "web"})
+
+	for _, test := range []struct {
+		filter    *NamespaceFilter
+		namespace string
+		watches   bool
+		matches   bool
+	}{
+		{nil, "foo", true, true},
+		{&NamespaceFilter{}, "foo", true, true},
+		{&NamespaceFilter{Namespaces: []string{"foo", "bar"}}, "bar", true, true},
+		{&NamespaceFilter{Namespaces: []string{"foo", "bar"}}, "baz", false, false},
+		{&NamespaceFilter{Selector: selector, NamespaceStore: namespaces}, "foo", true, true},
+		{&NamespaceFilter{Selector: selector, NamespaceStore: namespaces}, "bar", true, false},
+		{&NamespaceFilter{Selector: selector, NamespaceStore: namespaces}, "baz", true, false},
+		{&NamespaceFilter{Namespaces: []string{"bar"}, Selector: selector, NamespaceStore: namespaces}, "foo", false, false},
+	} {
+		if watches := test.filter.Watches(test.namespace); watches != test.watches {
+			t.Errorf("Unexpected watch %t for filter %+v and namespace %q", watches, test.filter, test.namespace)
+		}
+		if matches := test.filter.Matches(test.namespace); matches != test.matches {
+			t.Errorf("Unexpected match %t for filter %+v and namespace %q", matches, test.filter, test.namespace)
+		}
+	}
+}
+
+func TestNamespaceFilterWatchNamespace(t *testing.T) {
+	for _, test := range []struct {
+		filter   *NamespaceFilter
+		expected string
+	}{
+		{nil, metav1.NamespaceAll},
+		{&NamespaceFilter{}, metav1.NamespaceAll},
+		{&NamespaceFilter{Namespaces: []string{"foo"}}, "foo"},
+		{&NamespaceFilter{Namespaces: []string{"foo", "bar"}}, metav1.NamespaceAll},
+	} {
+		if namespace := test.filter.WatchNamespace(); namespace != test.expected {
+			t.Errorf("Unexpected namespace %q for filter %+v, expected %q", namespace, test.filter, test.expected)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package ingress
+
+import (
+	"fmt"
+
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/client-go/tools/cache"
+
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+// NewNetworkingV1ListWatch lists and watches the selected networking.k8s.io/v1
+// ingresses, converting them to extensions/v1beta1 ingresses so the rest of
+// the controller can work with a single representation.
+func NewNetworkingV1ListWatch(c cache.Getter, selection *Selection) *cache.ListWatch {
+	lw := newSelectorListWatch(c, "ingresses", selection.Namespaces.WatchNamespace(), selection.LabelSelector, selection.FieldSelector)
+	return newListWatch(lw, selection, func(obj runtime.Object) (*v1beta1.Ingress, error) {
+		ingress, ok := obj.(*networkingv1.Ingress)
+		if !ok {
+			return nil, fmt.Errorf("unexpected object type %T", obj)
+		}
+		converted := FromNetworkingV1(ingress)
+		if className := ingress.Spec.IngressClassName; className != nil {
+			if converted.Annotations == nil {
+				converted.Annotations = make(map[string]string)
+			}
+			converted.Annotations[ClassNameAnnotation] = *className
+		}
+		return converted, nil
+	})
+}
+
+// FromNetworkingV1 converts a networking.k8s.io/v1 ingress to its
+// extensions/v1beta1 equivalent. Paths are translated according to their
+// path type into regular expressions, which are anchored for Exact and Prefix
+// paths. Backends referencing resources other than services are dropped,
+// since vulcand has no way to route to them.
+func FromNetworkingV1(in *networkingv1.Ingress) *v1beta1.Ingress {
+	out := &v1beta1.Ingress{
+		ObjectMeta: *in.ObjectMeta.DeepCopy(),
+		Status: v1beta1.IngressStatus{
+			LoadBalancer: *in.Status.LoadBalancer.DeepCopy(),
+		},
+	}
+	out.Kind = "Ingress"
+	out.APIVersion = networkingv1.SchemeGroupVersion.String()
+
+	if in.Spec.DefaultBackend != nil {
+		out.Spec.Backend = fromNetworkingV1Backend(in.Spec.DefaultBackend)
+	}
+
+	for _, tls := range in.Spec.TLS {
+		out.Spec.TLS = append(out.Spec.TLS, v1beta1.IngressTLS{
+			Hosts:      append([]string(nil), tls.Hosts...),
+			SecretName: tls.SecretName,
+		})
+	}
+
+	for _, rule := range in.Spec.Rules {
+		r := v1beta1.IngressRule{Host: rule.Host}
+		if rule.HTTP != nil {
+			r.HTTP = &v1beta1.HTTPIngressRuleValue{}
+			for _, path := range rule.HTTP.Paths {
+				backend := fromNetworkingV1Backend(&path.Backend)
+				if backend == nil {
+					continue
+				}
+				pathType := networkingv1.PathTypeImplementationSpecific
+				if path.PathType != nil {
+					pathType = *path.PathType
+				}
+				r.HTTP.Paths = append(r.HTTP.Paths, v1beta1.HTTPIngressPath{
+					Path:    vulcan.CreatePathRegexp(path.Path, string(pathType)),
+					Backend: *backend,
+				})
+			}
+		}
+		out.Spec.Rules = append(out.Spec.Rules, r)
+	}
+
+	return out
+}
+
+func fromNetworkingV1Backend(in *networkingv1.IngressBackend) *v1beta1.IngressBackend {
+	if in.Service == nil {
+		return nil
+	}
+	out := &v1beta1.IngressBackend{ServiceName: in.Service.Name}
+	if in.Service.Port.Name != "" {
+		out.ServicePort = intstr.FromString(in.Service.Port.Name)
+	} else {
+		out.ServicePort = intstr.FromInt(int(in.Service.Port.Number))
+	}
+	return out
+}
@@ -0,0 +1,97 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
+)
+
+func TestFromNetworkingV1(t *testing.T) {
+	exact := networkingv1.PathTypeExact
+	prefix := networkingv1.PathTypePrefix
+
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+		},
+		Spec: networkingv1.IngressSpec{
+			DefaultBackend: &networkingv1.IngressBackend{
+				Service: &networkingv1.IngressServiceBackend{
+					Name: "default",
+					Port: networkingv1.ServiceBackendPort{Number: 80},
+				},
+			},
+			TLS: []networkingv1.IngressTLS{
+				{Hosts: []string{"example.com"}, SecretName: "example"},
+			},
+			Rules: []networkingv1.IngressRule{
+				{
+					Host: "example.com",
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{
+							{
+								Path:     "/foo",
+								PathType: &exact,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "foo",
+										Port: networkingv1.ServiceBackendPort{Name: "http"},
+									},
+								},
+							},
+							{
+								Path:     "/bar",
+								PathType: &prefix,
+								Backend: networkingv1.IngressBackend{
+									Service: &networkingv1.IngressServiceBackend{
+										Name: "bar",
+										Port: networkingv1.ServiceBackendPort{Number: 8080},
+									},
+								},
+							},
+							{
+								Path: "/baz",
+								Backend: networkingv1.IngressBackend{
+									Resource: &networkingv1.TypedLocalObjectReference{Kind: "Bucket", Name: "baz"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	converted := FromNetworkingV1(ingress)
+
+	if converted.Name != "ingress" || converted.Namespace != "namespace" {
+		t.Errorf("Unexpected object meta %s/%s", converted.Namespace, converted.Name)
+	}
+
+	expectedBackend := &v1beta1.IngressBackend{ServiceName: "default", ServicePort: intstr.FromInt(80)}
+	if !reflect.DeepEqual(converted.Spec.Backend, expectedBackend) {
+		t.Errorf("Unexpected default backend %v, expected %v", converted.Spec.Backend, expectedBackend)
+	}
+
+	expectedTLS := []v1beta1.IngressTLS{{Hosts: []string{"example.com"}, SecretName: "example"}}
+	if !reflect.DeepEqual(converted.Spec.TLS, expectedTLS) {
+		t.Errorf("Unexpected tls %v, expected %v", converted.Spec.TLS, expectedTLS)
+	}
+
+	expectedPaths := []v1beta1.HTTPIngressPath{
+		{Path: "^/foo$", Backend: v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromString("http")}},
+		{Path: "^/bar(/.*)?$", Backend: v1beta1.IngressBackend{ServiceName: "bar", ServicePort: intstr.FromInt(8080)}},
+	}
+	if len(converted.Spec.Rules) != 1 || converted.Spec.Rules[0].Host != "example.com" {
+		t.Fatalf("Unexpected rules %v", converted.Spec.Rules)
+	}
+	if paths := converted.Spec.Rules[0].HTTP.Paths; !reflect.DeepEqual(paths, expectedPaths) {
+		t.Errorf("Unexpected paths %v, expected %v", paths, expectedPaths)
+	}
+}
@@ -0,0 +1,231 @@
+package ingress
+
+import (
+	"encoding/json"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	corev1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/core/v1"
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+// ReadinessGate manages a pod condition which pods can list among their
+// readiness gates, so that they only become ready once they are registered
+// with every vulcand backend routing to them. Without it, rolling deployments
+// may terminate old pods before vulcand knows about the new ones.
+type ReadinessGate struct {
+	condition v1.PodConditionType
+	client    rest.Interface
+	indexer   cache.Indexer
+	informer  cache.Controller
+}
+
+// NewReadinessGate returns a readiness gate managing the condition of the
+// given type. The indexer and informer hold the pods, which are patched
+// through the client.
+func NewReadinessGate(condition string, client rest.Interface, indexer cache.Indexer, informer cache.Controller) *ReadinessGate {
+	return &ReadinessGate{
+		condition: v1.PodConditionType(condition),
+		client:    client,
+		indexer:   indexer,
+		informer:  informer,
+	}
+}
+
+// Waiting reports whether the pod declares the readiness gate and all its
+// containers are ready, so it waits for nothing but its registration with
+// vulcand. Such pods are registered even though they are not ready yet.
+func (g *ReadinessGate) Waiting(pod *corev1.Pod) bool {
+	if !pod.HasReadinessGate(g.condition) {
+		return false
+	}
+	ready := pod.Condition(corev1.ContainersReady)
+	return ready != nil && ready.Status == v1.ConditionTrue
+}
+
+// Pending reports whether the pod waits for its registration with vulcand and
+// the condition isn't true yet.
+func (g *ReadinessGate) Pending(pod *corev1.Pod) bool {
+	if !g.Waiting(pod) {
+		return false
+	}
+	condition := pod.Condition(g.condition)
+	return condition == nil || condition.Status != v1.ConditionTrue
+}
+
+// Pod looks up the pod by its key in the format <ns>/<name>.
+func (g *ReadinessGate) Pod(key string) (*corev1.Pod, bool) {
+	item, exists, err := g.indexer.GetByKey(key)
+	if err != nil || !exists {
+		return nil, false
+	}
+	return item.(*corev1.Pod), true
+}
+
+// Register sets the condition of the pod to true.
+func (g *ReadinessGate) Register(pod *corev1.Pod) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"status": map[string]interface{}{
+			"conditions": []v1.PodCondition{{
+				Type:               g.condition,
+				Status:             v1.ConditionTrue,
+				LastTransitionTime: metav1.Now(),
+				Reason:             "Registered",
+				Message:            "Pod is registered with vulcand",
+			}},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	return g.client.Patch(types.StrategicMergePatchType).
+		Namespace(pod.Namespace).
+		Resource("pods").
+		Name(pod.Name).
+		SubResource("status").
+		Body(patch).
+		Do().
+		Error()
+}
+
+// waiting reports whether the endpoint address belongs to a pod which waits
+// for its registration with vulcand.
+func (c *Controller) waiting(namespace string) func(v1.EndpointAddress) bool {
+	if c.readiness == nil {
+		return nil
+	}
+	return func(address v1.EndpointAddress) bool {
+		ref := address.TargetRef
+		if ref == nil || ref.Kind != "Pod" {
+			return false
+		}
+		pod, ok := c.readiness.Pod(namespace + "/" + ref.Name)
+		return ok && c.readiness.Waiting(pod)
+	}
+}
+
+// register takes note of the servers synced to vulcand for the ingress, and
+// sets the readiness gate of the pods among them which are now registered
+// with every backend routing to them.
+func (c *Controller) register(key string, desired *vulcan.State) error {
+	if c.readiness == nil {
+		return nil
+	}
+
+	servers := serverIDs(desired)
+
+	c.registeredMu.Lock()
+	c.registered[key] = servers
+	c.registeredMu.Unlock()
+
+	namespace, _, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range servers.List() {
+		pod, ok := c.readiness.Pod(namespace + "/" + id)
+		if !ok || !c.readiness.Pending(pod) {
+			continue
+		}
+		if err := c.registerPod(pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerPending sets the readiness gate of every pending pod which is
+// registered with every backend routing to it. This catches pods which no
+// ingress routes to, which are never synced. It only runs as part of the
+// reconciliation, so such pods wait for up to the reconcile period.
+func (c *Controller) registerPending() {
+	if c.readiness == nil {
+		return
+	}
+	for _, item := range c.readiness.indexer.List() {
+		pod := item.(*corev1.Pod)
+		if !c.readiness.Pending(pod) {
+			continue
+		}
+		if err := c.registerPod(pod); err != nil {
+			c.logger.WithError(err).WithField("pod", pod.Namespace+"/"+pod.Name).Error("Failed updating pod readiness gate")
+		}
+	}
+}
+
+// registerPod sets the readiness gate of the pod, if it is registered with
+// every backend routing to it.
+func (c *Controller) registerPod(pod *corev1.Pod) error {
+	if !c.registeredEverywhere(pod) {
+		return nil
+	}
+	if err := c.readiness.Register(pod); err != nil {
+		return err
+	}
+	c.logger.WithField("pod", pod.Namespace+"/"+pod.Name).Info("Pod registered with vulcand")
+	return nil
+}
+
+// registeredEverywhere reports whether the pod was synced to vulcand by every
+// ingress routing to it, through any service it backs.
+func (c *Controller) registeredEverywhere(pod *corev1.Pod) bool {
+	endpoints, err := c.endpointsIndexer.ByIndex(PodIndex, pod.Namespace+"/"+pod.Name)
+	if err != nil {
+		return false
+	}
+	for _, item := range endpoints {
+		service, err := cache.MetaNamespaceKeyFunc(item)
+		if err != nil {
+			return false
+		}
+		ingresses, err := c.indexer.ByIndex(ServiceIndex, service)
+		if err != nil {
+			return false
+		}
+		for _, item := range ingresses {
+			ingress := item.(*v1beta1.Ingress)
+			key, err := cache.MetaNamespaceKeyFunc(ingress)
+			if err != nil || !c.selected(key) {
+				continue
+			}
+			desired, err := c.desired(ingress)
+			if err != nil {
+				return false
+			}
+			if !serverIDs(desired).Has(pod.Name) {
+				continue
+			}
+			c.registeredMu.Lock()
+			registered := c.registered[key].Has(pod.Name)
+			c.registeredMu.Unlock()
+			if !registered {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// forgetRegistered drops the servers noted for the ingress.
+func (c *Controller) forgetRegistered(key string) {
+	c.registeredMu.Lock()
+	delete(c.registered, key)
+	c.registeredMu.Unlock()
+}
+
+func serverIDs(state *vulcan.State) sets.String {
+	ids := sets.NewString()
+	for _, backend := range state.Backends {
+		for _, server := range backend.Servers {
+			ids.Insert(server.Id)
+		}
+	}
+	return ids
+}
@@ -0,0 +1,118 @@
+package ingress
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/sirupsen/logrus"
+
+	corev1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/core/v1"
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+const testReadinessGate = "vulcand.ingress/registered"
+
+func newTestPod(name string, gate bool, conditions ...v1.PodCondition) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace"},
+		Status:     corev1.PodStatus{Conditions: conditions},
+	}
+	if gate {
+		pod.Spec.ReadinessGates = []corev1.PodReadinessGate{{ConditionType: testReadinessGate}}
+	}
+	return pod
+}
+
+func TestReadinessGate(t *testing.T) {
+	gate := NewReadinessGate(testReadinessGate, nil, nil, nil)
+
+	containersReady := v1.PodCondition{Type: corev1.ContainersReady, Status: v1.ConditionTrue}
+	containersNotReady := v1.PodCondition{Type: corev1.ContainersReady, Status: v1.ConditionFalse}
+	registered := v1.PodCondition{Type: testReadinessGate, Status: v1.ConditionTrue}
+
+	for _, test := range []struct {
+		pod     *corev1.Pod
+		waiting bool
+		pending bool
+	}{
+		{newTestPod("no-gate", false, containersReady), false, false},
+		{newTestPod("not-ready", true, containersNotReady), false, false},
+		{newTestPod("pending", true, containersReady), true, true},
+		{newTestPod("registered", true, containersReady, registered), true, false},
+	} {
+		if waiting := gate.Waiting(test.pod); waiting != test.waiting {
+			t.Errorf("Unexpected waiting %t for pod %s", waiting, test.pod.Name)
+		}
+		if pending := gate.Pending(test.pod); pending != test.pending {
+			t.Errorf("Unexpected pending %t for pod %s", pending, test.pod.Name)
+		}
+	}
+}
+
+func TestRegisteredEverywhere(t *testing.T) {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	pod := newTestPod("foo-1", true, v1.PodCondition{Type: corev1.ContainersReady, Status: v1.ConditionTrue})
+	pods := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	pods.Add(pod)
+
+	services := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{})
+	services.Add(&v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "namespace"},
+		Spec:       v1.ServiceSpec{Ports: []v1.ServicePort{{Name: "http", Port: 80}}},
+	})
+
+	endpoints := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{PodIndex: IndexByPod})
+	endpoints.Add(&v1.Endpoints{
+		ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: "namespace"},
+		Subsets: []v1.EndpointSubset{{
+			NotReadyAddresses: []v1.EndpointAddress{
+				{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-1"}},
+			},
+			Ports: []v1.EndpointPort{{Name: "http", Port: 8080}},
+		}},
+	})
+
+	ingresses := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{ServiceIndex: IndexByService})
+	for _, name := range []string{"a", "b"} {
+		ingresses.Add(&v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "namespace"},
+			Spec: v1beta1.IngressSpec{
+				Backend: &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromString("http")},
+			},
+		})
+	}
+
+	c := &Controller{
+		indexer:          ingresses,
+		servicesIndexer:  services,
+		endpointsIndexer: endpoints,
+		secretsIndexer:   cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		vulcan:           vulcan.New("http://localhost:8182", 0, nil),
+		logger:           logger,
+		readiness:        NewReadinessGate(testReadinessGate, nil, pods, nil),
+		registered:       make(map[string]sets.String),
+	}
+
+	if c.registeredEverywhere(pod) {
+		t.Fatal("Expected pod not to be registered before any sync")
+	}
+
+	c.registered["namespace/a"] = sets.NewString("foo-1")
+	if c.registeredEverywhere(pod) {
+		t.Fatal("Expected pod not to be registered before every ingress synced it")
+	}
+
+	c.registered["namespace/b"] = sets.NewString("foo-1")
+	if !c.registeredEverywhere(pod) {
+		t.Fatal("Expected pod to be registered once every ingress synced it")
+	}
+}
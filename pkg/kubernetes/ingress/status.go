@@ -0,0 +1,189 @@
+package ingress
+
+import (
+	"net"
+	"sort"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	extensionsv1beta1 "k8s.io/client-go/kubernetes/typed/extensions/v1beta1"
+	"k8s.io/client-go/rest"
+
+	networkingv1 "github.com/yieldr/vulcand-ingress/pkg/kubernetes/apis/networking/v1"
+)
+
+// Publisher resolves the load balancer addresses published to the status of
+// the ingresses served by the controller.
+type Publisher interface {
+	Addresses() ([]v1.LoadBalancerIngress, error)
+}
+
+// StaticPublisher publishes a fixed list of IPs or hostnames.
+type StaticPublisher []string
+
+func (p StaticPublisher) Addresses() ([]v1.LoadBalancerIngress, error) {
+	addresses := make([]v1.LoadBalancerIngress, 0, len(p))
+	for _, address := range p {
+		addresses = append(addresses, loadBalancerIngress(address))
+	}
+	return sortAddresses(addresses), nil
+}
+
+// ServicePublisher publishes the addresses of the service exposing vulcand.
+// The load balancer addresses of the service are preferred, falling back to
+// its external IPs.
+type ServicePublisher struct {
+	client    corev1.ServicesGetter
+	namespace string
+	name      string
+}
+
+func NewServicePublisher(client corev1.ServicesGetter, namespace, name string) *ServicePublisher {
+	return &ServicePublisher{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (p *ServicePublisher) Addresses() ([]v1.LoadBalancerIngress, error) {
+	service, err := p.client.Services(p.namespace).Get(p.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var addresses []v1.LoadBalancerIngress
+	if len(service.Status.LoadBalancer.Ingress) > 0 {
+		addresses = append(addresses, service.Status.LoadBalancer.Ingress...)
+	} else {
+		for _, ip := range service.Spec.ExternalIPs {
+			addresses = append(addresses, loadBalancerIngress(ip))
+		}
+	}
+	return sortAddresses(addresses), nil
+}
+
+// StatusUpdater writes the load balancer status of ingresses.
+type StatusUpdater interface {
+	// Update replaces the load balancer status of the ingress. Ingresses which
+	// no longer exist are ignored.
+	Update(namespace, name string, update func(status *v1.LoadBalancerStatus)) error
+}
+
+// ExtensionsV1beta1StatusUpdater updates the status of extensions/v1beta1
+// ingresses.
+type ExtensionsV1beta1StatusUpdater struct {
+	client extensionsv1beta1.IngressesGetter
+}
+
+func NewExtensionsV1beta1StatusUpdater(client extensionsv1beta1.IngressesGetter) *ExtensionsV1beta1StatusUpdater {
+	return &ExtensionsV1beta1StatusUpdater{client: client}
+}
+
+func (u *ExtensionsV1beta1StatusUpdater) Update(namespace, name string, update func(status *v1.LoadBalancerStatus)) error {
+	ingress, err := u.client.Ingresses(namespace).Get(name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status := ingress.Status.LoadBalancer.DeepCopy()
+	update(status)
+	if equalAddresses(status.Ingress, ingress.Status.LoadBalancer.Ingress) {
+		return nil
+	}
+	ingress.Status = v1beta1.IngressStatus{LoadBalancer: *status}
+	_, err = u.client.Ingresses(namespace).UpdateStatus(ingress)
+	return err
+}
+
+// NetworkingV1StatusUpdater updates the status of networking.k8s.io/v1
+// ingresses.
+type NetworkingV1StatusUpdater struct {
+	client rest.Interface
+}
+
+func NewNetworkingV1StatusUpdater(client rest.Interface) *NetworkingV1StatusUpdater {
+	return &NetworkingV1StatusUpdater{client: client}
+}
+
+func (u *NetworkingV1StatusUpdater) Update(namespace, name string, update func(status *v1.LoadBalancerStatus)) error {
+	ingress := &networkingv1.Ingress{}
+	err := u.client.Get().Namespace(namespace).Resource("ingresses").Name(name).Do().Into(ingress)
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	status := ingress.Status.LoadBalancer.DeepCopy()
+	update(status)
+	if equalAddresses(status.Ingress, ingress.Status.LoadBalancer.Ingress) {
+		return nil
+	}
+	ingress.Status = networkingv1.IngressStatus{LoadBalancer: *status}
+	return u.client.Put().Namespace(namespace).Resource("ingresses").Name(name).SubResource("status").Body(ingress).Do().Error()
+}
+
+// PublishAddresses returns a status update which replaces the load balancer
+// addresses with the given ones.
+func PublishAddresses(addresses []v1.LoadBalancerIngress) func(*v1.LoadBalancerStatus) {
+	return func(status *v1.LoadBalancerStatus) {
+		status.Ingress = append([]v1.LoadBalancerIngress(nil), addresses...)
+	}
+}
+
+// UnpublishAddresses returns a status update which removes the given load
+// balancer addresses, leaving any address published by someone else.
+func UnpublishAddresses(addresses []v1.LoadBalancerIngress) func(*v1.LoadBalancerStatus) {
+	return func(status *v1.LoadBalancerStatus) {
+		var remaining []v1.LoadBalancerIngress
+		for _, address := range status.Ingress {
+			if !containsAddress(addresses, address) {
+				remaining = append(remaining, address)
+			}
+		}
+		status.Ingress = remaining
+	}
+}
+
+func loadBalancerIngress(address string) v1.LoadBalancerIngress {
+	if net.ParseIP(address) != nil {
+		return v1.LoadBalancerIngress{IP: address}
+	}
+	return v1.LoadBalancerIngress{Hostname: address}
+}
+
+func sortAddresses(addresses []v1.LoadBalancerIngress) []v1.LoadBalancerIngress {
+	sort.Slice(addresses, func(i, j int) bool {
+		if addresses[i].IP != addresses[j].IP {
+			return addresses[i].IP < addresses[j].IP
+		}
+		return addresses[i].Hostname < addresses[j].Hostname
+	})
+	return addresses
+}
+
+func containsAddress(addresses []v1.LoadBalancerIngress, address v1.LoadBalancerIngress) bool {
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
+
+func equalAddresses(a, b []v1.LoadBalancerIngress) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
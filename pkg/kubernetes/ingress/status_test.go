@@ -0,0 +1,45 @@
+package ingress
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+)
+
+func TestStaticPublisher(t *testing.T) {
+	addresses, err := StaticPublisher{"lb.example.com", "10.0.0.2", "10.0.0.1"}.Addresses()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []v1.LoadBalancerIngress{
+		{Hostname: "lb.example.com"},
+		{IP: "10.0.0.1"},
+		{IP: "10.0.0.2"},
+	}
+	if !reflect.DeepEqual(addresses, expected) {
+		t.Errorf("Unexpected addresses %v, expected %v", addresses, expected)
+	}
+}
+
+func TestPublishAddresses(t *testing.T) {
+	ours := []v1.LoadBalancerIngress{{IP: "10.0.0.1"}, {Hostname: "lb.example.com"}}
+
+	status := &v1.LoadBalancerStatus{Ingress: []v1.LoadBalancerIngress{{IP: "10.0.0.9"}}}
+	PublishAddresses(ours)(status)
+	if !reflect.DeepEqual(status.Ingress, ours) {
+		t.Errorf("Unexpected published addresses %v, expected %v", status.Ingress, ours)
+	}
+
+	status.Ingress = append(status.Ingress, v1.LoadBalancerIngress{IP: "10.0.0.9"})
+	UnpublishAddresses(ours)(status)
+	expected := []v1.LoadBalancerIngress{{IP: "10.0.0.9"}}
+	if !reflect.DeepEqual(status.Ingress, expected) {
+		t.Errorf("Unexpected addresses after unpublishing %v, expected %v", status.Ingress, expected)
+	}
+
+	UnpublishAddresses(ours)(status)
+	if !reflect.DeepEqual(status.Ingress, expected) {
+		t.Errorf("Expected addresses published by someone else to be left, got %v", status.Ingress)
+	}
+}
@@ -0,0 +1,91 @@
+package leaderelection
+
+import (
+	"encoding/json"
+	"errors"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// LeaderAnnotation is the config map annotation holding the leader election
+// record. It is the one used by the client-go config map lock, so the lock can
+// be inspected with the same tooling.
+const LeaderAnnotation = "control-plane.alpha.kubernetes.io/leader"
+
+// ConfigMapLock keeps the leader election record in an annotation of a config
+// map.
+type ConfigMapLock struct {
+	client    corev1.ConfigMapsGetter
+	namespace string
+	name      string
+	identity  string
+
+	configMap *v1.ConfigMap
+}
+
+func NewConfigMapLock(client corev1.ConfigMapsGetter, namespace, name, identity string) *ConfigMapLock {
+	return &ConfigMapLock{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		identity:  identity,
+	}
+}
+
+func (l *ConfigMapLock) Get() (*Record, error) {
+	configMap, err := l.client.ConfigMaps(l.namespace).Get(l.name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	l.configMap = configMap
+	record := &Record{}
+	if data, ok := configMap.Annotations[LeaderAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), record); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
+
+func (l *ConfigMapLock) Create(record Record) error {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	l.configMap, err = l.client.ConfigMaps(l.namespace).Create(&v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   l.namespace,
+			Name:        l.name,
+			Annotations: map[string]string{LeaderAnnotation: string(data)},
+		},
+	})
+	return err
+}
+
+// Update writes the record to the config map last returned by Get. The update
+// fails with a conflict if the config map was modified in the meantime.
+func (l *ConfigMapLock) Update(record Record) error {
+	if l.configMap == nil {
+		return errors.New("config map lock not initialized, get or create it first")
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if l.configMap.Annotations == nil {
+		l.configMap.Annotations = make(map[string]string)
+	}
+	l.configMap.Annotations[LeaderAnnotation] = string(data)
+	l.configMap, err = l.client.ConfigMaps(l.namespace).Update(l.configMap)
+	return err
+}
+
+func (l *ConfigMapLock) Identity() string {
+	return l.identity
+}
+
+func (l *ConfigMapLock) Describe() string {
+	return l.namespace + "/" + l.name
+}
@@ -0,0 +1,318 @@
+// Package leaderelection elects a single leader among the replicas of the
+// controller, so that only one of them writes to vulcand at any time.
+//
+// The vendored client-go predates its leader election package, so this is a
+// slimmed down version of the same algorithm. A candidate becomes leader by
+// writing its identity into a lock record, and stays leader by renewing the
+// record before the lease expires. Candidates only take over a lock whose
+// record they haven't seen change for a full lease duration, which means that
+// they don't depend on the clocks of the replicas being in sync.
+package leaderelection
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Record is the leader election record stored in the lock.
+type Record struct {
+	HolderIdentity       string      `json:"holderIdentity"`
+	LeaseDurationSeconds int         `json:"leaseDurationSeconds"`
+	AcquireTime          metav1.Time `json:"acquireTime"`
+	RenewTime            metav1.Time `json:"renewTime"`
+	LeaderTransitions    int         `json:"leaderTransitions"`
+}
+
+// Lock stores the leader election record.
+type Lock interface {
+	// Get returns the current record. It returns a not found error if the lock
+	// doesn't exist yet.
+	Get() (*Record, error)
+	// Create creates the lock holding the record.
+	Create(record Record) error
+	// Update replaces the record of the lock, failing if the lock was modified
+	// since the last call to Get.
+	Update(record Record) error
+	// Identity of the candidate using the lock.
+	Identity() string
+	// Describe returns a human readable name of the lock.
+	Describe() string
+}
+
+// Callbacks are invoked as the leadership changes.
+type Callbacks struct {
+	// OnStartedLeading is called once the leadership was acquired. The stop
+	// channel is closed once leadership is lost or the elector is stopped.
+	OnStartedLeading func(stop <-chan struct{})
+	// OnStoppedLeading is called once leadership is lost.
+	OnStoppedLeading func()
+	// OnNewLeader is called whenever a different leader is observed. It is
+	// optional.
+	OnNewLeader func(identity string)
+}
+
+type Config struct {
+	Lock Lock
+	// LeaseDuration is the time candidates wait after the last observed
+	// change of the lock before taking over leadership.
+	LeaseDuration time.Duration
+	// RenewDeadline is the time the leader keeps retrying to renew the lease
+	// before giving up leadership. It must be shorter than the lease duration.
+	RenewDeadline time.Duration
+	// RetryPeriod is the time between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+	// ShutdownGracePeriod is the time OnStartedLeading may take to return
+	// once the stop channel is closed. The lease is renewed in the meantime,
+	// but it must be shorter than the lease duration, so that a leader which
+	// fails to renew can't outlive its lease.
+	ShutdownGracePeriod time.Duration
+	Callbacks           Callbacks
+	Logger              *logrus.Logger
+}
+
+type LeaderElector struct {
+	config Config
+	now    func() time.Time
+
+	mu             sync.Mutex
+	observedRecord Record
+	observedTime   time.Time
+}
+
+func NewLeaderElector(config Config) (*LeaderElector, error) {
+	if config.LeaseDuration <= config.RenewDeadline {
+		return nil, fmt.Errorf("lease duration %s must be greater than renew deadline %s", config.LeaseDuration, config.RenewDeadline)
+	}
+	if config.RenewDeadline <= config.RetryPeriod {
+		return nil, fmt.Errorf("renew deadline %s must be greater than retry period %s", config.RenewDeadline, config.RetryPeriod)
+	}
+	if config.ShutdownGracePeriod >= config.LeaseDuration {
+		return nil, fmt.Errorf("shutdown grace period %s must be less than lease duration %s", config.ShutdownGracePeriod, config.LeaseDuration)
+	}
+	if config.Callbacks.OnStartedLeading == nil || config.Callbacks.OnStoppedLeading == nil {
+		return nil, fmt.Errorf("leader election callbacks must not be nil")
+	}
+	return &LeaderElector{
+		config: config,
+		now:    time.Now,
+	}, nil
+}
+
+// Run blocks until leadership is acquired, then calls OnStartedLeading and
+// keeps renewing the lease. It returns once leadership is lost, calling
+// OnStoppedLeading, or once the stop channel is closed. In the latter case it
+// keeps renewing the lease until OnStartedLeading returns and then releases
+// it, so a standby can take over right away.
+func (le *LeaderElector) Run(stop <-chan struct{}) {
+	logger := le.config.Logger.WithFields(logrus.Fields{
+		"lock":     le.config.Lock.Describe(),
+		"identity": le.config.Lock.Identity(),
+	})
+
+	logger.Info("Attempting to acquire leadership")
+	if !le.acquire(stop) {
+		return
+	}
+	logger.Info("Acquired leadership")
+	isLeader.With().Set(1)
+
+	leading := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		le.config.Callbacks.OnStartedLeading(leading)
+	}()
+
+	le.renew(stop)
+	close(leading)
+
+	select {
+	case <-stop:
+		// Leadership is handed over only once the leader finished its work,
+		// so that the next leader doesn't race against it. The lease is
+		// renewed until then, otherwise a standby would take over once it
+		// expired.
+		le.renew(done)
+		select {
+		case <-done:
+		default:
+			logger.Error("Lost leadership before finishing work")
+			<-done
+		}
+		le.release()
+		logger.Info("Released leadership")
+		isLeader.With().Set(0)
+		return
+	default:
+	}
+
+	logger.Info("Lost leadership")
+	isLeader.With().Set(0)
+	le.config.Callbacks.OnStoppedLeading()
+}
+
+// IsLeader reports whether the last observed leader is this candidate.
+func (le *LeaderElector) IsLeader() bool {
+	return le.Leader() == le.config.Lock.Identity()
+}
+
+// Leader returns the identity of the last observed leader.
+func (le *LeaderElector) Leader() string {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.observedRecord.HolderIdentity
+}
+
+// acquire retries to acquire the lease until it succeeds or the stop channel
+// is closed.
+func (le *LeaderElector) acquire(stop <-chan struct{}) bool {
+	for {
+		if le.tryAcquireOrRenew() {
+			return true
+		}
+		select {
+		case <-stop:
+			return false
+		case <-time.After(wait.Jitter(le.config.RetryPeriod, 1.2)):
+		}
+	}
+}
+
+// renew keeps renewing the lease until it fails to do so within the renew
+// deadline, or the stop channel is closed.
+func (le *LeaderElector) renew(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case <-time.After(le.config.RetryPeriod):
+		}
+		deadline := le.now().Add(le.config.RenewDeadline)
+		for !le.tryAcquireOrRenew() {
+			if le.now().After(deadline) {
+				return
+			}
+			select {
+			case <-stop:
+				return
+			case <-time.After(le.config.RetryPeriod):
+			}
+		}
+	}
+}
+
+// tryAcquireOrRenew creates the lock or updates it, if it is held by this
+// candidate or its lease expired. It reports whether this candidate holds the
+// lease afterwards.
+func (le *LeaderElector) tryAcquireOrRenew() bool {
+	lock := le.config.Lock
+	now := metav1.NewTime(le.now())
+
+	record := Record{
+		HolderIdentity:       lock.Identity(),
+		LeaseDurationSeconds: int(le.config.LeaseDuration / time.Second),
+		AcquireTime:          now,
+		RenewTime:            now,
+	}
+
+	current, err := lock.Get()
+	if err != nil {
+		if !errors.IsNotFound(err) {
+			le.config.Logger.WithError(err).Error("Failed getting leader election lock")
+			return false
+		}
+		if err := lock.Create(record); err != nil {
+			le.config.Logger.WithError(err).Error("Failed creating leader election lock")
+			return false
+		}
+		le.observe(record)
+		return true
+	}
+
+	le.observe(*current)
+
+	if current.HolderIdentity != "" &&
+		current.HolderIdentity != lock.Identity() &&
+		le.expires().After(now.Time) {
+		return false
+	}
+
+	if current.HolderIdentity == lock.Identity() {
+		record.AcquireTime = current.AcquireTime
+		record.LeaderTransitions = current.LeaderTransitions
+	} else {
+		record.LeaderTransitions = current.LeaderTransitions + 1
+	}
+
+	if err := lock.Update(record); err != nil {
+		le.config.Logger.WithError(err).Error("Failed updating leader election lock")
+		return false
+	}
+	le.observe(record)
+	return true
+}
+
+// release clears the holder of the lock if it is held by this candidate.
+func (le *LeaderElector) release() {
+	lock := le.config.Lock
+	current, err := lock.Get()
+	if err != nil {
+		le.config.Logger.WithError(err).Error("Failed getting leader election lock")
+		return
+	}
+	if current.HolderIdentity != lock.Identity() {
+		return
+	}
+	now := metav1.NewTime(le.now())
+	record := Record{
+		LeaseDurationSeconds: 1,
+		AcquireTime:          now,
+		RenewTime:            now,
+		LeaderTransitions:    current.LeaderTransitions,
+	}
+	if err := lock.Update(record); err != nil {
+		le.config.Logger.WithError(err).Error("Failed releasing leader election lock")
+		return
+	}
+	le.observe(record)
+}
+
+// observe takes note of the record, restarting the lease if it changed since
+// it was last observed.
+func (le *LeaderElector) observe(record Record) {
+	le.mu.Lock()
+	previous := le.observedRecord
+	if !reflect.DeepEqual(previous, record) {
+		le.observedRecord = record
+		le.observedTime = le.now()
+	}
+	le.mu.Unlock()
+
+	if record.HolderIdentity == previous.HolderIdentity {
+		return
+	}
+	leader.Delete(previous.HolderIdentity)
+	if record.HolderIdentity == "" {
+		return
+	}
+	le.config.Logger.WithField("leader", record.HolderIdentity).Info("New leader elected")
+	leader.With(record.HolderIdentity).Set(1)
+	if le.config.Callbacks.OnNewLeader != nil {
+		le.config.Callbacks.OnNewLeader(record.HolderIdentity)
+	}
+}
+
+// expires returns the time at which the lease of the observed record expires.
+func (le *LeaderElector) expires() time.Time {
+	le.mu.Lock()
+	defer le.mu.Unlock()
+	return le.observedTime.Add(le.config.LeaseDuration)
+}
@@ -0,0 +1,230 @@
+package leaderelection
+
+import (
+	"io/ioutil"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/sirupsen/logrus"
+)
+
+// memoryLock is a lock shared by candidates through a pointer to the record.
+type memoryLock struct {
+	record   **Record
+	identity string
+}
+
+// memoryLockMu guards the records of memory locks, which may be shared by
+// candidates running concurrently.
+var memoryLockMu sync.Mutex
+
+func (l *memoryLock) Get() (*Record, error) {
+	memoryLockMu.Lock()
+	defer memoryLockMu.Unlock()
+	if *l.record == nil {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, "lock")
+	}
+	record := **l.record
+	return &record, nil
+}
+
+func (l *memoryLock) Create(record Record) error {
+	memoryLockMu.Lock()
+	defer memoryLockMu.Unlock()
+	*l.record = &record
+	return nil
+}
+
+func (l *memoryLock) Update(record Record) error {
+	memoryLockMu.Lock()
+	defer memoryLockMu.Unlock()
+	*l.record = &record
+	return nil
+}
+
+func (l *memoryLock) Identity() string { return l.identity }
+func (l *memoryLock) Describe() string { return "memory" }
+
+func newTestElector(t *testing.T, record **Record, identity string, now *time.Time) *LeaderElector {
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+	le, err := NewLeaderElector(Config{
+		Lock:          &memoryLock{record: record, identity: identity},
+		LeaseDuration: 15 * time.Second,
+		RenewDeadline: 10 * time.Second,
+		RetryPeriod:   2 * time.Second,
+		Callbacks: Callbacks{
+			OnStartedLeading: func(<-chan struct{}) {},
+			OnStoppedLeading: func() {},
+		},
+		Logger: logger,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	le.now = func() time.Time { return *now }
+	return le
+}
+
+func TestTryAcquireOrRenew(t *testing.T) {
+	var record *Record
+	now := time.Now()
+
+	a := newTestElector(t, &record, "a", &now)
+	b := newTestElector(t, &record, "b", &now)
+
+	if !a.tryAcquireOrRenew() {
+		t.Fatal("Expected a to acquire the missing lock")
+	}
+	if b.tryAcquireOrRenew() {
+		t.Fatal("Expected b not to acquire the lock held by a")
+	}
+	if !a.IsLeader() || b.IsLeader() || b.Leader() != "a" {
+		t.Errorf("Expected a to be observed as leader, got %q and %q", a.Leader(), b.Leader())
+	}
+
+	// a keeps renewing, so b never sees the lease expire.
+	for i := 0; i < 10; i++ {
+		now = now.Add(5 * time.Second)
+		if !a.tryAcquireOrRenew() {
+			t.Fatal("Expected a to renew the lease")
+		}
+		if b.tryAcquireOrRenew() {
+			t.Fatal("Expected b not to acquire a renewed lease")
+		}
+	}
+
+	// a stops renewing, b takes over once the lease expired.
+	now = now.Add(10 * time.Second)
+	if b.tryAcquireOrRenew() {
+		t.Fatal("Expected b not to acquire the lease before it expired")
+	}
+	now = now.Add(6 * time.Second)
+	if !b.tryAcquireOrRenew() {
+		t.Fatal("Expected b to acquire the expired lease")
+	}
+	if record.HolderIdentity != "b" || record.LeaderTransitions != 1 {
+		t.Errorf("Unexpected record %+v", record)
+	}
+
+	if a.tryAcquireOrRenew() {
+		t.Fatal("Expected a not to acquire the lease taken over by b")
+	}
+	if a.IsLeader() {
+		t.Error("Expected a to observe b as leader")
+	}
+}
+
+func TestNewLeaderElectorValidation(t *testing.T) {
+	callbacks := Callbacks{
+		OnStartedLeading: func(<-chan struct{}) {},
+		OnStoppedLeading: func() {},
+	}
+	for _, config := range []Config{
+		{LeaseDuration: 10 * time.Second, RenewDeadline: 10 * time.Second, RetryPeriod: 2 * time.Second, Callbacks: callbacks},
+		{LeaseDuration: 15 * time.Second, RenewDeadline: 2 * time.Second, RetryPeriod: 2 * time.Second, Callbacks: callbacks},
+		{LeaseDuration: 15 * time.Second, RenewDeadline: 10 * time.Second, RetryPeriod: 2 * time.Second},
+		{LeaseDuration: 15 * time.Second, RenewDeadline: 10 * time.Second, RetryPeriod: 2 * time.Second, ShutdownGracePeriod: 15 * time.Second, Callbacks: callbacks},
+	} {
+		if _, err := NewLeaderElector(config); err == nil {
+			t.Errorf("Expected config %+v to be rejected", config)
+		}
+	}
+}
+
+func TestRelease(t *testing.T) {
+	var record *Record
+	now := time.Now()
+
+	a := newTestElector(t, &record, "a", &now)
+	b := newTestElector(t, &record, "b", &now)
+
+	if !a.tryAcquireOrRenew() {
+		t.Fatal("Expected a to acquire the missing lock")
+	}
+	if b.tryAcquireOrRenew() {
+		t.Fatal("Expected b not to acquire the lock held by a")
+	}
+
+	b.release()
+	if record.HolderIdentity != "a" {
+		t.Fatalf("Expected b not to release the lock held by a, got %+v", record)
+	}
+
+	a.release()
+	if record.HolderIdentity != "" {
+		t.Fatalf("Expected a to release the lock, got %+v", record)
+	}
+	if !b.tryAcquireOrRenew() {
+		t.Error("Expected b to acquire the released lock right away")
+	}
+}
+
+func TestRunRenewsUntilFinished(t *testing.T) {
+	var record *Record
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	stop := make(chan struct{})
+	started := make(chan struct{})
+
+	newElector := func(identity string, callbacks Callbacks) *LeaderElector {
+		le, err := NewLeaderElector(Config{
+			Lock:                &memoryLock{record: &record, identity: identity},
+			LeaseDuration:       300 * time.Millisecond,
+			RenewDeadline:       200 * time.Millisecond,
+			RetryPeriod:         20 * time.Millisecond,
+			ShutdownGracePeriod: 200 * time.Millisecond,
+			Callbacks:           callbacks,
+			Logger:              logger,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return le
+	}
+
+	a := newElector("a", Callbacks{
+		OnStartedLeading: func(leading <-chan struct{}) {
+			close(started)
+			<-leading
+			// Work in progress outlives the lease.
+			time.Sleep(time.Second)
+		},
+		OnStoppedLeading: func() {
+			t.Error("Unexpected loss of leadership")
+		},
+	})
+	b := newElector("b", Callbacks{
+		OnStartedLeading: func(<-chan struct{}) {},
+		OnStoppedLeading: func() {},
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		a.Run(stop)
+	}()
+
+	<-started
+	close(stop)
+
+	for {
+		select {
+		case <-finished:
+			if !b.tryAcquireOrRenew() {
+				t.Error("Expected b to acquire the lease released by a")
+			}
+			return
+		case <-time.After(20 * time.Millisecond):
+			if b.tryAcquireOrRenew() {
+				t.Fatal("Expected b not to acquire the lease before a finished")
+			}
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package leaderelection
+
+import (
+	"github.com/yieldr/vulcand-ingress/pkg/metrics"
+)
+
+var (
+	leader = metrics.NewGaugeVec(
+		"vulcand_ingress_leader",
+		"Leader observed by this replica, set to 1 for the identity of the leader.",
+		"identity")
+	isLeader = metrics.NewGaugeVec(
+		"vulcand_ingress_is_leader",
+		"Whether this replica is the leader.")
+)
+
+func init() {
+	metrics.MustRegister(leader, isLeader)
+}
@@ -0,0 +1,157 @@
+package ownership
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// ConfigMapStore persists records in a config map, with one entry per ingress
+// UID. Records are cached in memory and written through whenever they change,
+// so they survive a restart of the controller.
+//
+// All records share a single config map, which the API server caps at 1 MiB.
+// Writes which would exceed that size fail, and with them the sync of the
+// ingress. Clusters with more ingresses than fit have to split them among
+// several instances of the controller, each with its own config map.
+type ConfigMapStore struct {
+	client    corev1.ConfigMapsGetter
+	namespace string
+	name      string
+
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// maxConfigMapSize is the maximum size of the data of a config map.
+const maxConfigMapSize = 1 << 20
+
+func NewConfigMapStore(client corev1.ConfigMapsGetter, namespace, name string) *ConfigMapStore {
+	return &ConfigMapStore{
+		client:    client,
+		namespace: namespace,
+		name:      name,
+		records:   make(map[string]Record),
+	}
+}
+
+// Load reads the records from the config map, creating it if it doesn't exist
+// yet.
+func (s *ConfigMapStore) Load() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	configMap, err := s.client.ConfigMaps(s.namespace).Get(s.name, metav1.GetOptions{})
+	if errors.IsNotFound(err) {
+		configMap, err = s.client.ConfigMaps(s.namespace).Create(&v1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: s.namespace,
+				Name:      s.name,
+			},
+		})
+	}
+	if err != nil {
+		return err
+	}
+
+	records := make(map[string]Record, len(configMap.Data))
+	for uid, data := range configMap.Data {
+		var record Record
+		if err := json.Unmarshal([]byte(data), &record); err != nil {
+			return err
+		}
+		records[uid] = record
+	}
+	s.records = records
+	return nil
+}
+
+func (s *ConfigMapStore) List() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records
+}
+
+func (s *ConfigMapStore) Put(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	// Most syncs don't change anything, so they don't write either.
+	if stored, ok := s.records[record.UID]; ok {
+		if storedData, err := json.Marshal(stored); err == nil && string(storedData) == string(data) {
+			return nil
+		}
+	}
+
+	err = s.update(func(configMap *v1.ConfigMap) {
+		if configMap.Data == nil {
+			configMap.Data = make(map[string]string)
+		}
+		configMap.Data[record.UID] = string(data)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.records[record.UID] = record
+	return nil
+}
+
+func (s *ConfigMapStore) Delete(uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := s.update(func(configMap *v1.ConfigMap) {
+		delete(configMap.Data, uid)
+	})
+	if err != nil {
+		return err
+	}
+
+	delete(s.records, uid)
+	return nil
+}
+
+// update applies the change to the latest version of the config map, retrying
+// a few times if it was modified concurrently.
+func (s *ConfigMapStore) update(change func(*v1.ConfigMap)) error {
+	var err error
+	for i := 0; i < 5; i++ {
+		var configMap *v1.ConfigMap
+		configMap, err = s.client.ConfigMaps(s.namespace).Get(s.name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		change(configMap)
+		if size := dataSize(configMap); size > maxConfigMapSize {
+			return fmt.Errorf("ownership records of %d bytes exceed the size limit of config map %s/%s", size, s.namespace, s.name)
+		}
+		_, err = s.client.ConfigMaps(s.namespace).Update(configMap)
+		if !errors.IsConflict(err) {
+			return err
+		}
+	}
+	return err
+}
+
+func dataSize(configMap *v1.ConfigMap) int {
+	size := 0
+	for key, value := range configMap.Data {
+		size += len(key) + len(value)
+	}
+	return size
+}
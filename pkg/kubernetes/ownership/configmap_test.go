@@ -0,0 +1,84 @@
+package ownership
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+// fakeConfigMaps holds a single config map and counts the updates made to it.
+type fakeConfigMaps struct {
+	corev1.ConfigMapInterface
+
+	configMap *v1.ConfigMap
+	updates   int
+}
+
+func (f *fakeConfigMaps) ConfigMaps(namespace string) corev1.ConfigMapInterface {
+	return f
+}
+
+func (f *fakeConfigMaps) Get(name string, options metav1.GetOptions) (*v1.ConfigMap, error) {
+	if f.configMap == nil {
+		return nil, errors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return f.configMap.DeepCopy(), nil
+}
+
+func (f *fakeConfigMaps) Create(configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	f.configMap = configMap.DeepCopy()
+	return configMap, nil
+}
+
+func (f *fakeConfigMaps) Update(configMap *v1.ConfigMap) (*v1.ConfigMap, error) {
+	f.configMap = configMap.DeepCopy()
+	f.updates++
+	return configMap, nil
+}
+
+func TestConfigMapStorePut(t *testing.T) {
+	client := &fakeConfigMaps{}
+	store := NewConfigMapStore(client, "default", "vulcand-ingress")
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	record := Record{UID: "1", Ingress: "ns/api", Frontends: []string{"ns.api.foo"}}
+	for i := 0; i < 3; i++ {
+		if err := store.Put(record); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if client.updates != 1 {
+		t.Errorf("Expected unchanged records not to be written, got %d updates", client.updates)
+	}
+
+	record.Frontends = append(record.Frontends, "ns.api.bar")
+	if err := store.Put(record); err != nil {
+		t.Fatal(err)
+	}
+	if client.updates != 2 {
+		t.Errorf("Expected the changed record to be written, got %d updates", client.updates)
+	}
+}
+
+func TestConfigMapStoreSizeLimit(t *testing.T) {
+	client := &fakeConfigMaps{}
+	store := NewConfigMapStore(client, "default", "vulcand-ingress")
+	if err := store.Load(); err != nil {
+		t.Fatal(err)
+	}
+
+	record := Record{UID: "1", Ingress: "ns/api", Frontends: []string{strings.Repeat("a", maxConfigMapSize)}}
+	if err := store.Put(record); err == nil {
+		t.Fatal("Expected records exceeding the config map size limit to be rejected")
+	}
+	if len(store.List()) != 0 || client.updates != 0 {
+		t.Error("Expected the rejected record not to be stored")
+	}
+}
@@ -0,0 +1,39 @@
+package ownership
+
+import "sync"
+
+// MemoryStore keeps records in memory. Records are lost when the controller
+// restarts, so it is meant for tests, like those of the ingress controller,
+// which need a Store without a cluster.
+type MemoryStore struct {
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{records: make(map[string]Record)}
+}
+
+func (s *MemoryStore) List() []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	records := make([]Record, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	return records
+}
+
+func (s *MemoryStore) Put(record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.UID] = record
+	return nil
+}
+
+func (s *MemoryStore) Delete(uid string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, uid)
+	return nil
+}
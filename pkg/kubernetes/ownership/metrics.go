@@ -0,0 +1,48 @@
+package ownership
+
+import (
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/yieldr/vulcand-ingress/pkg/metrics"
+)
+
+// RegisterMetrics registers gauges reporting the number of vulcand objects
+// recorded in the store.
+func RegisterMetrics(store Store) {
+	metrics.MustRegister(
+		metrics.NewGaugeFunc(
+			"vulcand_ingress_managed_hosts",
+			"Number of vulcand hosts managed by the controller.",
+			count(store, func(r Record) []string { return r.Hosts })),
+		metrics.NewGaugeFunc(
+			"vulcand_ingress_managed_frontends",
+			"Number of vulcand frontends managed by the controller.",
+			count(store, func(r Record) []string { return r.Frontends })),
+		metrics.NewGaugeFunc(
+			"vulcand_ingress_managed_backends",
+			"Number of vulcand backends managed by the controller.",
+			count(store, func(r Record) []string { return append(append([]string{}, r.Backends...), r.SharedBackends...) })),
+		metrics.NewGaugeFunc(
+			"vulcand_ingress_managed_middlewares",
+			"Number of vulcand middlewares managed by the controller.",
+			count(store, func(r Record) []string {
+				keys := make([]string, 0, len(r.Middlewares))
+				for _, key := range r.Middlewares {
+					keys = append(keys, key.String())
+				}
+				return keys
+			})))
+}
+
+// count returns a function counting the distinct objects recorded in the
+// store. Hosts and shared backends are recorded by every ingress using them,
+// but are only counted once.
+func count(store Store, objects func(Record) []string) func() float64 {
+	return func() float64 {
+		ids := sets.NewString()
+		for _, record := range store.List() {
+			ids.Insert(objects(record)...)
+		}
+		return float64(ids.Len())
+	}
+}
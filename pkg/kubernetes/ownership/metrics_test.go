@@ -0,0 +1,18 @@
+package ownership
+
+import "testing"
+
+func TestCount(t *testing.T) {
+	store := NewMemoryStore()
+	store.Put(Record{UID: "1", Ingress: "ns/api", Hosts: []string{"example.com", "api.example.com"}, Backends: []string{"ns.api"}, SharedBackends: []string{"ns.foo"}})
+	store.Put(Record{UID: "2", Ingress: "ns/web", Hosts: []string{"example.com"}, SharedBackends: []string{"ns.foo", "ns.bar"}})
+
+	hosts := count(store, func(r Record) []string { return r.Hosts })
+	if n := hosts(); n != 2 {
+		t.Errorf("Expected 2 hosts, got %v", n)
+	}
+	backends := count(store, func(r Record) []string { return append(append([]string{}, r.Backends...), r.SharedBackends...) })
+	if n := backends(); n != 3 {
+		t.Errorf("Expected 3 backends, got %v", n)
+	}
+}
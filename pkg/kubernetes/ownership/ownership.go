@@ -0,0 +1,326 @@
+// Package ownership keeps track of which vulcand objects were created by the
+// controller and on behalf of which ingress.
+//
+// vulcand objects carry no metadata, so there is no way to tell from vulcand
+// alone whether an object was created by the controller or by someone else.
+// Instead, every time an ingress is synced, the IDs of the objects it produced
+// are recorded under the ingress UID. Deletes and reconciliation only ever
+// touch recorded objects.
+//
+// Hosts and shared backends may be recorded by several ingresses. The records
+// count their references, and they are only deleted along with the last
+// ingress using them. The settings of a host, such as its key pair, are
+// configured by a single ingress, the first one to record it. Other ingresses
+// may only use the host with the same settings, unless the host was configured
+// with a fallback certificate of the controller, in which case an ingress with
+// a certificate of its own takes the host over.
+package ownership
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/vulcand/vulcand/engine"
+
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+// Record lists the vulcand objects owned by a single ingress.
+type Record struct {
+	// UID of the ingress which owns the objects.
+	UID string `json:"uid"`
+	// Ingress is the key of the ingress in the format <ns>/<name>.
+	Ingress string   `json:"ingress"`
+	Hosts   []string `json:"hosts,omitempty"`
+	// HostSettings maps the hosts whose settings the ingress configures to a
+	// fingerprint of those settings.
+	HostSettings map[string]string `json:"hostSettings,omitempty"`
+	// Fallbacks are the configured hosts which use a certificate of the
+	// controller rather than one of the ingress.
+	Fallbacks []string `json:"fallbacks,omitempty"`
+	Frontends []string `json:"frontends,omitempty"`
+	Backends  []string `json:"backends,omitempty"`
+	// SharedBackends are backends which other ingresses may use as well.
+	SharedBackends []string               `json:"sharedBackends,omitempty"`
+	Middlewares    []engine.MiddlewareKey `json:"middlewares,omitempty"`
+}
+
+// Store persists ownership records.
+type Store interface {
+	// List returns all records.
+	List() []Record
+	// Put creates or replaces the record with the same UID.
+	Put(record Record) error
+	// Delete removes the record with the given UID.
+	Delete(uid string) error
+}
+
+// NewRecord records the objects of the state as owned by the ingress. The
+// ingress configures the settings of every host not configured by another
+// ingress according to the existing records, or only configured with a
+// fallback certificate. The fallbacks are the hosts of the state which use a
+// certificate of the controller.
+func NewRecord(uid, ingress string, state *vulcan.State, fallbacks []string, records []Record) Record {
+	record := Record{
+		UID:     uid,
+		Ingress: ingress,
+	}
+	configured := Configured(records, ingress)
+	isFallback := sets.NewString(fallbacks...)
+	for _, host := range state.Hosts {
+		record.Hosts = append(record.Hosts, host.Name)
+		if configured.Has(host.Name) {
+			continue
+		}
+		if record.HostSettings == nil {
+			record.HostSettings = make(map[string]string)
+		}
+		record.HostSettings[host.Name] = Fingerprint(host)
+		if isFallback.Has(host.Name) {
+			record.Fallbacks = append(record.Fallbacks, host.Name)
+		}
+	}
+	for _, frontend := range state.Frontends {
+		record.Frontends = append(record.Frontends, frontend.Id)
+	}
+	for _, backend := range state.Backends {
+		if backend.Shared {
+			record.SharedBackends = append(record.SharedBackends, backend.Id)
+		} else {
+			record.Backends = append(record.Backends, backend.Id)
+		}
+	}
+	for _, middleware := range state.Middlewares {
+		record.Middlewares = append(record.Middlewares, middleware.Key())
+	}
+	return record
+}
+
+// State returns the owned objects identified by their keys. It holds enough
+// information to delete the objects, but not to create them.
+func (r Record) State() *vulcan.State {
+	state := &vulcan.State{}
+	for _, host := range r.Hosts {
+		state.AddHost(engine.Host{Name: host})
+	}
+	for _, frontend := range r.Frontends {
+		state.AddFrontend(engine.Frontend{Id: frontend})
+	}
+	for _, backend := range r.Backends {
+		state.AddBackend(vulcan.Backend{Backend: engine.Backend{Id: backend}})
+	}
+	for _, backend := range r.SharedBackends {
+		state.AddBackend(vulcan.Backend{Backend: engine.Backend{Id: backend}, Shared: true})
+	}
+	for _, key := range r.Middlewares {
+		state.AddMiddleware(vulcan.Middleware{
+			Middleware:  engine.Middleware{Id: key.Id},
+			FrontendKey: key.FrontendKey,
+		})
+	}
+	return state
+}
+
+// Fingerprint returns a hash of the settings of the host.
+func Fingerprint(host engine.Host) string {
+	data, _ := json.Marshal(host.Settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// Configured returns the hosts which ingresses other than the given one
+// configure with a certificate of their own.
+func Configured(records []Record, ingress string) sets.String {
+	configured := sets.NewString()
+	for _, record := range records {
+		if record.Ingress == ingress {
+			continue
+		}
+		fallbacks := sets.NewString(record.Fallbacks...)
+		for host := range record.HostSettings {
+			if !fallbacks.Has(host) {
+				configured.Insert(host)
+			}
+		}
+	}
+	return configured
+}
+
+// ForIngress returns the records of all ingresses with the given key. There is
+// usually at most one, but an ingress which was deleted and created again
+// under the same name will have a record for each UID until it is synced.
+func ForIngress(records []Record, ingress string) []Record {
+	var matches []Record
+	for _, record := range records {
+		if record.Ingress == ingress {
+			matches = append(matches, record)
+		}
+	}
+	return matches
+}
+
+// Unowned returns the objects of the state which exist in vulcand but have not
+// been recorded as owned by any ingress.
+func Unowned(records []Record, state, existing *vulcan.State) *vulcan.State {
+
+	owned := &vulcan.State{}
+	for _, record := range records {
+		owned.Merge(record.State())
+	}
+
+	unowned := &vulcan.State{}
+	for _, host := range state.Hosts {
+		if existing.HasHost(host.Name) && !owned.HasHost(host.Name) {
+			unowned.AddHost(host)
+		}
+	}
+	for _, frontend := range state.Frontends {
+		if existing.HasFrontend(frontend.Id) && !owned.HasFrontend(frontend.Id) {
+			unowned.AddFrontend(frontend)
+		}
+	}
+	for _, backend := range state.Backends {
+		if existing.HasBackend(backend.Id) && !owned.HasBackend(backend.Id) {
+			unowned.AddBackend(backend)
+		}
+	}
+	for _, middleware := range state.Middlewares {
+		if existing.HasMiddleware(middleware.Key()) && !owned.HasMiddleware(middleware.Key()) {
+			unowned.AddMiddleware(middleware)
+		}
+	}
+	return unowned
+}
+
+// Conflicts checks that syncing the desired state for the ingress won't modify
+// any object the ingress does not own. Existing objects must be owned by the
+// same ingress, with the exception of hosts and shared backends which may be
+// shared between ingresses as long as the controller created them. A host
+// configured by another ingress may only be used with the same settings, unless
+// it was configured with a fallback certificate.
+func Conflicts(records []Record, ingress string, desired, existing *vulcan.State) error {
+
+	hosts := make(map[string]string)
+	configurers := make(map[string]string)
+	fingerprints := make(map[string]string)
+	frontends := make(map[string]string)
+	backends := make(map[string]string)
+	sharedBackends := make(map[string]bool)
+	middlewares := make(map[engine.MiddlewareKey]string)
+
+	for _, record := range records {
+		for _, host := range record.Hosts {
+			hosts[host] = record.Ingress
+		}
+		fallbacks := sets.NewString(record.Fallbacks...)
+		for host, fingerprint := range record.HostSettings {
+			if fallbacks.Has(host) {
+				continue
+			}
+			configurers[host] = record.Ingress
+			fingerprints[host] = fingerprint
+		}
+		for _, frontend := range record.Frontends {
+			frontends[frontend] = record.Ingress
+		}
+		for _, backend := range record.Backends {
+			backends[backend] = record.Ingress
+		}
+		for _, backend := range record.SharedBackends {
+			backends[backend] = record.Ingress
+			sharedBackends[backend] = true
+		}
+		for _, middleware := range record.Middlewares {
+			middlewares[middleware] = record.Ingress
+		}
+	}
+
+	for _, host := range desired.Hosts {
+		if _, owned := hosts[host.Name]; !owned && existing.HasHost(host.Name) {
+			return fmt.Errorf("host %s was not created by the controller", host.Name)
+		}
+		if owner, ok := configurers[host.Name]; ok && owner != ingress && fingerprints[host.Name] != Fingerprint(host) {
+			return fmt.Errorf("host %s is configured with different settings by ingress %s", host.Name, owner)
+		}
+	}
+	for _, frontend := range desired.Frontends {
+		if err := conflict("frontend", frontend.Id, frontends, ingress, existing.HasFrontend(frontend.Id)); err != nil {
+			return err
+		}
+	}
+	for _, backend := range desired.Backends {
+		if backend.Shared && sharedBackends[backend.Id] {
+			continue
+		}
+		if err := conflict("backend", backend.Id, backends, ingress, existing.HasBackend(backend.Id)); err != nil {
+			return err
+		}
+	}
+	for _, middleware := range desired.Middlewares {
+		owner, owned := middlewares[middleware.Key()]
+		if !existing.HasMiddleware(middleware.Key()) {
+			continue
+		}
+		if !owned {
+			return fmt.Errorf("middleware %s was not created by the controller", middleware.Key())
+		}
+		if owner != ingress {
+			return fmt.Errorf("middleware %s is owned by ingress %s", middleware.Key(), owner)
+		}
+	}
+
+	return nil
+}
+
+func conflict(kind, id string, owners map[string]string, ingress string, exists bool) error {
+	if !exists {
+		return nil
+	}
+	owner, owned := owners[id]
+	if !owned {
+		return fmt.Errorf("%s %s was not created by the controller", kind, id)
+	}
+	if owner != ingress {
+		return fmt.Errorf("%s %s is owned by ingress %s", kind, id, owner)
+	}
+	return nil
+}
+
+// Release removes the hosts and shared backends which are still owned by other
+// ingresses from the state, so that deleting it leaves them in place.
+func Release(records []Record, ingress string, state *vulcan.State) *vulcan.State {
+
+	hosts := make(map[string]bool)
+	backends := make(map[string]bool)
+	for _, record := range records {
+		if record.Ingress == ingress {
+			continue
+		}
+		for _, host := range record.Hosts {
+			hosts[host] = true
+		}
+		for _, backend := range record.SharedBackends {
+			backends[backend] = true
+		}
+	}
+
+	released := &vulcan.State{
+		Frontends:   state.Frontends,
+		Middlewares: state.Middlewares,
+	}
+	for _, host := range state.Hosts {
+		if !hosts[host.Name] {
+			released.AddHost(host)
+		}
+	}
+	for _, backend := range state.Backends {
+		if !backend.Shared || !backends[backend.Id] {
+			released.AddBackend(backend)
+		}
+	}
+	return released
+}
@@ -0,0 +1,236 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/vulcand/vulcand/engine"
+
+	"github.com/yieldr/vulcand-ingress/pkg/vulcan"
+)
+
+func TestRecordState(t *testing.T) {
+	state := &vulcan.State{
+		Hosts: []engine.Host{{Name: "example.com"}},
+		Backends: []vulcan.Backend{
+			{Backend: engine.Backend{Id: "ns.api.foo"}},
+			{Backend: engine.Backend{Id: "ns.bar"}, Shared: true},
+		},
+		Frontends: []engine.Frontend{{Id: "ns.api.foo"}},
+		Middlewares: []vulcan.Middleware{{
+			FrontendKey: engine.FrontendKey{Id: "ns.api.foo"},
+			Middleware:  engine.Middleware{Id: "ns.api.foo.ratelimit"},
+		}},
+	}
+
+	record := NewRecord("uid", "ns/api", state, nil, nil)
+
+	if stale := vulcan.Stale(state, record.State()); !stale.Empty() {
+		t.Errorf("Unexpected objects missing from record %v", stale)
+	}
+	if stale := vulcan.Stale(record.State(), state); !stale.Empty() {
+		t.Errorf("Unexpected objects added to record %v", stale)
+	}
+	if len(record.SharedBackends) != 1 || !record.State().Backends[1].Shared {
+		t.Errorf("Expected shared backend to be recorded as shared, got %+v", record)
+	}
+}
+
+func TestConflicts(t *testing.T) {
+	records := []Record{
+		{
+			UID:            "1",
+			Ingress:        "ns/api",
+			Hosts:          []string{"example.com"},
+			Frontends:      []string{"ns.api.foo"},
+			Backends:       []string{"ns.api.foo"},
+			SharedBackends: []string{"ns.foo"},
+		},
+	}
+
+	existing := &vulcan.State{
+		Hosts: []engine.Host{
+			{Name: "example.com"},
+			{Name: "manual.example.com"},
+		},
+		Backends: []vulcan.Backend{
+			{Backend: engine.Backend{Id: "ns.api.foo"}},
+			{Backend: engine.Backend{Id: "manual"}},
+			{Backend: engine.Backend{Id: "ns.foo"}},
+		},
+		Frontends: []engine.Frontend{
+			{Id: "ns.api.foo"},
+			{Id: "manual"},
+		},
+	}
+
+	for name, test := range map[string]struct {
+		ingress  string
+		desired  *vulcan.State
+		conflict bool
+	}{
+		"owned": {"ns/api", &vulcan.State{
+			Backends:  []vulcan.Backend{{Backend: engine.Backend{Id: "ns.api.foo"}}},
+			Frontends: []engine.Frontend{{Id: "ns.api.foo"}},
+		}, false},
+		"new": {"ns/api", &vulcan.State{
+			Backends:  []vulcan.Backend{{Backend: engine.Backend{Id: "ns.api.bar"}}},
+			Frontends: []engine.Frontend{{Id: "ns.api.bar"}},
+		}, false},
+		"shared host": {"ns/web", &vulcan.State{
+			Hosts: []engine.Host{{Name: "example.com"}},
+		}, false},
+		"foreign host": {"ns/api", &vulcan.State{
+			Hosts: []engine.Host{{Name: "manual.example.com"}},
+		}, true},
+		"foreign frontend": {"ns/api", &vulcan.State{
+			Frontends: []engine.Frontend{{Id: "manual"}},
+		}, true},
+		"foreign backend": {"ns/api", &vulcan.State{
+			Backends: []vulcan.Backend{{Backend: engine.Backend{Id: "manual"}}},
+		}, true},
+		"other ingress": {"ns/web", &vulcan.State{
+			Frontends: []engine.Frontend{{Id: "ns.api.foo"}},
+		}, true},
+		"shared backend": {"ns/web", &vulcan.State{
+			Backends: []vulcan.Backend{{Backend: engine.Backend{Id: "ns.foo"}, Shared: true}},
+		}, false},
+		"other ingress backend": {"ns/web", &vulcan.State{
+			Backends: []vulcan.Backend{{Backend: engine.Backend{Id: "ns.api.foo"}, Shared: true}},
+		}, true},
+		"foreign shared backend": {"ns/web", &vulcan.State{
+			Backends: []vulcan.Backend{{Backend: engine.Backend{Id: "manual"}, Shared: true}},
+		}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			err := Conflicts(records, test.ingress, test.desired, existing)
+			if test.conflict && err == nil {
+				t.Error("Expected a conflict")
+			}
+			if !test.conflict && err != nil {
+				t.Errorf("Unexpected conflict %s", err)
+			}
+		})
+	}
+}
+
+func TestHostSettings(t *testing.T) {
+	keyPair := &engine.KeyPair{Cert: []byte("cert"), Key: []byte("key")}
+	otherKeyPair := &engine.KeyPair{Cert: []byte("other cert"), Key: []byte("other key")}
+
+	host := engine.Host{Name: "example.com", Settings: engine.HostSettings{KeyPair: keyPair}}
+	otherHost := engine.Host{Name: "example.com", Settings: engine.HostSettings{KeyPair: otherKeyPair}}
+
+	api := NewRecord("1", "ns/api", &vulcan.State{Hosts: []engine.Host{host}}, nil, nil)
+	if api.HostSettings["example.com"] != Fingerprint(host) {
+		t.Fatalf("Expected ns/api to configure the host, got %+v", api)
+	}
+
+	records := []Record{api}
+	existing := &vulcan.State{Hosts: []engine.Host{host}}
+
+	if err := Conflicts(records, "other/web", &vulcan.State{Hosts: []engine.Host{host}}, existing); err != nil {
+		t.Errorf("Unexpected conflict using the host with the same settings: %s", err)
+	}
+	if err := Conflicts(records, "other/web", &vulcan.State{Hosts: []engine.Host{otherHost}}, existing); err == nil {
+		t.Error("Expected a conflict replacing the key pair of a host configured by another ingress")
+	}
+	if err := Conflicts(records, "ns/api", &vulcan.State{Hosts: []engine.Host{otherHost}}, existing); err != nil {
+		t.Errorf("Unexpected conflict updating the key pair of a configured host: %s", err)
+	}
+
+	web := NewRecord("2", "other/web", &vulcan.State{Hosts: []engine.Host{host}}, nil, records)
+	if len(web.Hosts) != 1 || len(web.HostSettings) != 0 {
+		t.Errorf("Expected other/web to use the host without configuring it, got %+v", web)
+	}
+
+	// Once the configuring ingress is gone, the next one takes over.
+	web = NewRecord("2", "other/web", &vulcan.State{Hosts: []engine.Host{otherHost}}, nil, []Record{web})
+	if web.HostSettings["example.com"] != Fingerprint(otherHost) {
+		t.Errorf("Expected other/web to configure the host, got %+v", web)
+	}
+}
+
+func TestFallbackHostSettings(t *testing.T) {
+	wildcard := engine.Host{Name: "example.com", Settings: engine.HostSettings{KeyPair: &engine.KeyPair{Cert: []byte("wildcard")}}}
+	explicit := engine.Host{Name: "example.com", Settings: engine.HostSettings{KeyPair: &engine.KeyPair{Cert: []byte("explicit")}}}
+
+	web := NewRecord("1", "ns/web", &vulcan.State{Hosts: []engine.Host{wildcard}}, []string{"example.com"}, nil)
+	if web.HostSettings["example.com"] != Fingerprint(wildcard) || len(web.Fallbacks) != 1 {
+		t.Fatalf("Expected ns/web to configure the host with a fallback, got %+v", web)
+	}
+
+	records := []Record{web}
+	existing := &vulcan.State{Hosts: []engine.Host{wildcard}}
+
+	if err := Conflicts(records, "ns/api", &vulcan.State{Hosts: []engine.Host{explicit}}, existing); err != nil {
+		t.Errorf("Unexpected conflict replacing a fallback certificate: %s", err)
+	}
+	if Configured(records, "ns/api").Has("example.com") {
+		t.Error("Expected a host configured with a fallback not to count as configured")
+	}
+
+	api := NewRecord("2", "ns/api", &vulcan.State{Hosts: []engine.Host{explicit}}, nil, records)
+	if api.HostSettings["example.com"] != Fingerprint(explicit) {
+		t.Fatalf("Expected ns/api to take the host over, got %+v", api)
+	}
+
+	// The explicit certificate wins over the fallback still recorded.
+	records = append(records, api)
+	if err := Conflicts(records, "ns/other", &vulcan.State{Hosts: []engine.Host{wildcard}}, existing); err == nil {
+		t.Error("Expected a conflict replacing an explicit certificate")
+	}
+	if !Configured(records, "ns/web").Has("example.com") {
+		t.Error("Expected the host to count as configured by ns/api")
+	}
+}
+
+func TestRelease(t *testing.T) {
+	records := []Record{
+		{UID: "1", Ingress: "ns/api", Hosts: []string{"example.com", "api.example.com"}},
+		{UID: "2", Ingress: "ns/web", Hosts: []string{"example.com"}},
+	}
+
+	released := Release(records, "ns/api", records[0].State())
+
+	if len(released.Hosts) != 1 || released.Hosts[0].Name != "api.example.com" {
+		t.Errorf("Unexpected released hosts %v", released.Hosts)
+	}
+}
+
+func TestReleaseSharedBackends(t *testing.T) {
+	records := []Record{
+		{UID: "1", Ingress: "ns/api", SharedBackends: []string{"ns.foo", "ns.bar"}},
+		{UID: "2", Ingress: "ns/web", SharedBackends: []string{"ns.foo"}},
+	}
+
+	released := Release(records, "ns/api", records[0].State())
+	if len(released.Backends) != 1 || released.Backends[0].Id != "ns.bar" {
+		t.Errorf("Unexpected released backends %v", released.Backends)
+	}
+
+	// Once the last ingress using it goes away, the backend is deleted.
+	released = Release(records[1:], "ns/web", records[1].State())
+	if len(released.Backends) != 1 || released.Backends[0].Id != "ns.foo" {
+		t.Errorf("Unexpected released backends %v", released.Backends)
+	}
+}
+
+func TestUnowned(t *testing.T) {
+	records := []Record{
+		{UID: "1", Ingress: "ns/api", Frontends: []string{"ns.api.foo"}},
+	}
+
+	existing := &vulcan.State{
+		Frontends: []engine.Frontend{{Id: "ns.api.foo"}, {Id: "ns.api.bar"}},
+	}
+
+	legacy := &vulcan.State{
+		Frontends: []engine.Frontend{{Id: "ns.api.foo"}, {Id: "ns.api.bar"}, {Id: "ns.api.baz"}},
+	}
+
+	unowned := Unowned(records, legacy, existing)
+
+	if len(unowned.Frontends) != 1 || unowned.Frontends[0].Id != "ns.api.bar" {
+		t.Errorf("Unexpected unowned frontends %v", unowned.Frontends)
+	}
+}
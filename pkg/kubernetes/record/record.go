@@ -0,0 +1,146 @@
+// Package record records Kubernetes events about the objects handled by the
+// controller.
+//
+// The vendored client-go predates a usable event broadcaster, so this is a
+// minimal recorder in the same spirit. Events are written asynchronously, and
+// repeated events are folded into the existing event by increasing its count,
+// so a resync doesn't flood the namespace with identical events.
+package record
+
+import (
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+	corev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/reference"
+
+	"github.com/sirupsen/logrus"
+)
+
+// EventRecorder records events on behalf of a component. It mirrors the
+// interface of the client-go event recorder.
+type EventRecorder interface {
+	// Event records an event of the given type (v1.EventTypeNormal or
+	// v1.EventTypeWarning) about the object.
+	Event(object runtime.Object, eventtype, reason, message string)
+	// Eventf is just like Event, but with Sprintf for the message field.
+	Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{})
+}
+
+// maxSeenEvents bounds the number of events remembered for folding repeated
+// events.
+const maxSeenEvents = 4096
+
+// Recorder writes events to the Kubernetes API.
+type Recorder struct {
+	client corev1.EventsGetter
+	source v1.EventSource
+	logger *logrus.Logger
+	events chan *v1.Event
+	seen   map[string]*v1.Event
+}
+
+func NewRecorder(client corev1.EventsGetter, component string, logger *logrus.Logger) *Recorder {
+	return &Recorder{
+		client: client,
+		source: v1.EventSource{Component: component},
+		logger: logger,
+		events: make(chan *v1.Event, 1000),
+		seen:   make(map[string]*v1.Event),
+	}
+}
+
+func (r *Recorder) Event(object runtime.Object, eventtype, reason, message string) {
+	ref, err := reference.GetReference(scheme.Scheme, object)
+	if err != nil {
+		r.logger.WithError(err).Error("Failed referencing event object")
+		return
+	}
+
+	now := metav1.Now()
+	event := &v1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%v.%x", ref.Name, now.UnixNano()),
+			Namespace: ref.Namespace,
+		},
+		InvolvedObject: *ref,
+		Reason:         reason,
+		Message:        message,
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           eventtype,
+		Source:         r.source,
+	}
+
+	// Recording an event must never block the caller, so events are dropped
+	// if the writer can't keep up.
+	select {
+	case r.events <- event:
+	default:
+		r.logger.WithFields(logrus.Fields{
+			"reason":  reason,
+			"message": message,
+		}).Warn("Dropping event, too many events queued")
+	}
+}
+
+func (r *Recorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	r.Event(object, eventtype, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// Run writes the recorded events until the stop channel is closed.
+func (r *Recorder) Run(stop <-chan struct{}) {
+	for {
+		select {
+		case <-stop:
+			return
+		case event := <-r.events:
+			if err := r.write(event); err != nil {
+				r.logger.WithError(err).WithField("reason", event.Reason).Error("Failed writing event")
+			}
+		}
+	}
+}
+
+// write creates the event, or increases the count of the same event if it was
+// written before.
+func (r *Recorder) write(event *v1.Event) error {
+	key := eventKey(event)
+	if previous, ok := r.seen[key]; ok {
+		update := previous.DeepCopy()
+		update.Count++
+		update.LastTimestamp = event.LastTimestamp
+		updated, err := r.client.Events(update.Namespace).Update(update)
+		if err == nil {
+			r.seen[key] = updated
+			return nil
+		}
+		// The previous event most likely expired, so a new one is created
+		// in its place.
+		delete(r.seen, key)
+	}
+
+	created, err := r.client.Events(event.Namespace).Create(event)
+	if err != nil {
+		return err
+	}
+	if len(r.seen) >= maxSeenEvents {
+		r.seen = make(map[string]*v1.Event)
+	}
+	r.seen[key] = created
+	return nil
+}
+
+// eventKey identifies events which are considered the same.
+func eventKey(event *v1.Event) string {
+	return fmt.Sprintf("%s/%s/%s/%s/%s",
+		event.InvolvedObject.UID,
+		event.InvolvedObject.Kind,
+		event.Type,
+		event.Reason,
+		event.Message)
+}
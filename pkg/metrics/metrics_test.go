@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRegistry(t *testing.T) {
+	counter := NewCounterVec("test_total", "Test counter.", "name", "result")
+	counter.With("foo", "success").Inc()
+	counter.With("foo", "success").Inc()
+	counter.With("bar\"baz", "error").Add(3)
+
+	gauge := NewGaugeVec("test_depth", "Test gauge.")
+	gauge.With().Inc()
+	gauge.With().Inc()
+	gauge.With().Dec()
+
+	histogram := NewHistogramVec("test_seconds", "Test histogram.", []float64{1, 0.5}, "name")
+	histogram.With("foo").Observe(0.2)
+	histogram.With("foo").Observe(0.7)
+	histogram.With("foo").Observe(2)
+
+	registry := NewRegistry()
+	registry.MustRegister(counter, gauge, histogram, NewGaugeFunc("test_value", "Test gauge func.", func() float64 { return 42 }))
+
+	recorder := httptest.NewRecorder()
+	registry.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{name="bar\"baz",result="error"} 3
+test_total{name="foo",result="success"} 2
+# HELP test_depth Test gauge.
+# TYPE test_depth gauge
+test_depth 1
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{name="foo",le="0.5"} 1
+test_seconds_bucket{name="foo",le="1"} 2
+test_seconds_bucket{name="foo",le="+Inf"} 3
+test_seconds_sum{name="foo"} 2.9
+test_seconds_count{name="foo"} 3
+# HELP test_value Test gauge func.
+# TYPE test_value gauge
+test_value 42
+`
+	if body := recorder.Body.String(); body != expected {
+		t.Errorf("Unexpected metrics\n%s\nexpected\n%s", body, expected)
+	}
+
+	counter.Delete("foo", "success")
+	var buf bytes.Buffer
+	counter.Write(&buf)
+	if bytes.Contains(buf.Bytes(), []byte(`name="foo"`)) {
+		t.Errorf("Expected deleted series to be gone, got\n%s", buf.String())
+	}
+}
@@ -0,0 +1,66 @@
+package metrics
+
+import (
+	"k8s.io/client-go/util/workqueue"
+)
+
+var (
+	workqueueDepth = NewGaugeVec(
+		"workqueue_depth",
+		"Current depth of the workqueue.",
+		"name")
+	workqueueAdds = NewCounterVec(
+		"workqueue_adds_total",
+		"Total number of items added to the workqueue.",
+		"name")
+	workqueueLatency = NewHistogramVec(
+		"workqueue_queue_latency_microseconds",
+		"Time items spend waiting in the workqueue before being processed.",
+		ExponentialBuckets(1000, 4, 10),
+		"name")
+	workqueueWorkDuration = NewHistogramVec(
+		"workqueue_work_duration_microseconds",
+		"Time it takes to process an item from the workqueue.",
+		ExponentialBuckets(1000, 4, 10),
+		"name")
+	workqueueRetries = NewCounterVec(
+		"workqueue_retries_total",
+		"Total number of retries handled by the workqueue.",
+		"name")
+)
+
+// WorkqueueProvider is a workqueue.MetricsProvider recording the metrics of
+// named workqueues. Latencies are reported in microseconds by the workqueue.
+type WorkqueueProvider struct{}
+
+// RegisterWorkqueueProvider registers the workqueue metrics and makes every
+// named workqueue created afterwards record them.
+func RegisterWorkqueueProvider() {
+	MustRegister(
+		workqueueDepth,
+		workqueueAdds,
+		workqueueLatency,
+		workqueueWorkDuration,
+		workqueueRetries)
+	workqueue.SetProvider(WorkqueueProvider{})
+}
+
+func (WorkqueueProvider) NewDepthMetric(name string) workqueue.GaugeMetric {
+	return workqueueDepth.With(name)
+}
+
+func (WorkqueueProvider) NewAddsMetric(name string) workqueue.CounterMetric {
+	return workqueueAdds.With(name)
+}
+
+func (WorkqueueProvider) NewLatencyMetric(name string) workqueue.SummaryMetric {
+	return workqueueLatency.With(name)
+}
+
+func (WorkqueueProvider) NewWorkDurationMetric(name string) workqueue.SummaryMetric {
+	return workqueueWorkDuration.With(name)
+}
+
+func (WorkqueueProvider) NewRetriesMetric(name string) workqueue.CounterMetric {
+	return workqueueRetries.With(name)
+}
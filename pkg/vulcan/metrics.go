@@ -0,0 +1,157 @@
+package vulcan
+
+import (
+	"time"
+
+	"github.com/vulcand/vulcand/engine"
+
+	"github.com/yieldr/vulcand-ingress/pkg/metrics"
+)
+
+var (
+	apiCalls = metrics.NewCounterVec(
+		"vulcand_ingress_vulcand_api_calls_total",
+		"Total number of vulcand API calls by operation and result.",
+		"operation", "result")
+	apiDuration = metrics.NewHistogramVec(
+		"vulcand_ingress_vulcand_api_duration_seconds",
+		"Latency of vulcand API calls by operation.",
+		metrics.DefBuckets,
+		"operation")
+)
+
+func init() {
+	metrics.MustRegister(apiCalls, apiDuration)
+}
+
+// observe records the outcome of a vulcand API call which started at start.
+func observe(operation string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	apiCalls.With(operation, result).Inc()
+	apiDuration.With(operation).Observe(time.Since(start).Seconds())
+}
+
+// The methods below shadow the ones of the vulcand API client, recording
+// metrics for every call.
+
+func (c *Client) GetStatus() error {
+	start := time.Now()
+	err := c.Client.GetStatus()
+	observe("GetStatus", start, err)
+	return err
+}
+
+func (c *Client) UpsertListener(l engine.Listener) error {
+	start := time.Now()
+	err := c.Client.UpsertListener(l)
+	observe("UpsertListener", start, err)
+	return err
+}
+
+func (c *Client) GetHosts() ([]engine.Host, error) {
+	start := time.Now()
+	result, err := c.Client.GetHosts()
+	observe("GetHosts", start, err)
+	return result, err
+}
+
+func (c *Client) UpsertHost(h engine.Host) error {
+	start := time.Now()
+	err := c.Client.UpsertHost(h)
+	observe("UpsertHost", start, err)
+	return err
+}
+
+func (c *Client) DeleteHost(hk engine.HostKey) error {
+	start := time.Now()
+	err := c.Client.DeleteHost(hk)
+	observe("DeleteHost", start, err)
+	return err
+}
+
+func (c *Client) GetFrontends() ([]engine.Frontend, error) {
+	start := time.Now()
+	result, err := c.Client.GetFrontends()
+	observe("GetFrontends", start, err)
+	return result, err
+}
+
+func (c *Client) UpsertFrontend(f engine.Frontend, ttl time.Duration) error {
+	start := time.Now()
+	err := c.Client.UpsertFrontend(f, ttl)
+	observe("UpsertFrontend", start, err)
+	return err
+}
+
+func (c *Client) DeleteFrontend(fk engine.FrontendKey) error {
+	start := time.Now()
+	err := c.Client.DeleteFrontend(fk)
+	observe("DeleteFrontend", start, err)
+	return err
+}
+
+func (c *Client) GetBackends() ([]engine.Backend, error) {
+	start := time.Now()
+	result, err := c.Client.GetBackends()
+	observe("GetBackends", start, err)
+	return result, err
+}
+
+func (c *Client) UpsertBackend(b engine.Backend) error {
+	start := time.Now()
+	err := c.Client.UpsertBackend(b)
+	observe("UpsertBackend", start, err)
+	return err
+}
+
+func (c *Client) DeleteBackend(bk engine.BackendKey) error {
+	start := time.Now()
+	err := c.Client.DeleteBackend(bk)
+	observe("DeleteBackend", start, err)
+	return err
+}
+
+func (c *Client) GetServers(bk engine.BackendKey) ([]engine.Server, error) {
+	start := time.Now()
+	result, err := c.Client.GetServers(bk)
+	observe("GetServers", start, err)
+	return result, err
+}
+
+func (c *Client) UpsertServer(bk engine.BackendKey, srv engine.Server, ttl time.Duration) error {
+	start := time.Now()
+	err := c.Client.UpsertServer(bk, srv, ttl)
+	observe("UpsertServer", start, err)
+	return err
+}
+
+func (c *Client) DeleteServer(sk engine.ServerKey) error {
+	start := time.Now()
+	err := c.Client.DeleteServer(sk)
+	observe("DeleteServer", start, err)
+	return err
+}
+
+func (c *Client) GetMiddlewares(fk engine.FrontendKey) ([]engine.Middleware, error) {
+	start := time.Now()
+	result, err := c.Client.GetMiddlewares(fk)
+	observe("GetMiddlewares", start, err)
+	return result, err
+}
+
+func (c *Client) UpsertMiddleware(fk engine.FrontendKey, m engine.Middleware, ttl time.Duration) error {
+	start := time.Now()
+	err := c.Client.UpsertMiddleware(fk, m, ttl)
+	observe("UpsertMiddleware", start, err)
+	return err
+}
+
+func (c *Client) DeleteMiddleware(mk engine.MiddlewareKey) error {
+	start := time.Now()
+	err := c.Client.DeleteMiddleware(mk)
+	observe("DeleteMiddleware", start, err)
+	return err
+}
@@ -0,0 +1,58 @@
+package vulcan
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/vulcand/vulcand/engine"
+
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/annotations"
+)
+
+// CreateOCSPSettings returns the OCSP stapling settings of the hosts of the
+// ingress. The OCSP annotations of the ingress override the given defaults.
+func CreateOCSPSettings(ingress *v1beta1.Ingress, defaults engine.OCSPSettings) (engine.OCSPSettings, error) {
+	ocsp := defaults
+	if value, ok := ingress.Annotations[annotations.OCSP]; ok {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return ocsp, fmt.Errorf("invalid annotation %s: %s", annotations.OCSP, err)
+		}
+		ocsp.Enabled = enabled
+	}
+	if value, ok := ingress.Annotations[annotations.OCSPPeriod]; ok {
+		ocsp.Period = value
+	}
+	if value, ok := ingress.Annotations[annotations.OCSPResponders]; ok {
+		ocsp.Responders = nil
+		for _, responder := range strings.Split(value, ",") {
+			if responder = strings.TrimSpace(responder); responder != "" {
+				ocsp.Responders = append(ocsp.Responders, responder)
+			}
+		}
+	}
+	if value, ok := ingress.Annotations[annotations.OCSPSkipSignatureCheck]; ok {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return ocsp, fmt.Errorf("invalid annotation %s: %s", annotations.OCSPSkipSignatureCheck, err)
+		}
+		ocsp.SkipSignatureCheck = skip
+	}
+	return ocsp, ValidateOCSPSettings(ocsp)
+}
+
+// ValidateOCSPSettings checks that vulcand is able to parse the refresh period
+// of the settings, and that it is positive.
+func ValidateOCSPSettings(ocsp engine.OCSPSettings) error {
+	period, err := ocsp.RefreshPeriod()
+	if err != nil {
+		return fmt.Errorf("invalid OCSP period %q: %s", ocsp.Period, err)
+	}
+	if period <= 0 {
+		return fmt.Errorf("invalid OCSP period %q: must be positive", ocsp.Period)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package vulcan
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+func TestCreateOCSPSettings(t *testing.T) {
+	defaults := engine.OCSPSettings{Enabled: true, Period: "2h"}
+
+	for name, test := range map[string]struct {
+		annotations map[string]string
+		expected    engine.OCSPSettings
+		err         bool
+	}{
+		"defaults": {nil, defaults, false},
+		"disabled": {map[string]string{
+			"ingress.kubernetes.io/ocsp": "false",
+		}, engine.OCSPSettings{Period: "2h"}, false},
+		"overridden": {map[string]string{
+			"ingress.kubernetes.io/ocsp-period":               "30m",
+			"ingress.kubernetes.io/ocsp-responders":           "http://ocsp.example.com, http://ocsp.example.org",
+			"ingress.kubernetes.io/ocsp-skip-signature-check": "true",
+		}, engine.OCSPSettings{
+			Enabled:            true,
+			Period:             "30m",
+			Responders:         []string{"http://ocsp.example.com", "http://ocsp.example.org"},
+			SkipSignatureCheck: true,
+		}, false},
+		"invalid enabled": {map[string]string{"ingress.kubernetes.io/ocsp": "maybe"}, engine.OCSPSettings{}, true},
+		"invalid period":  {map[string]string{"ingress.kubernetes.io/ocsp-period": "hourly"}, engine.OCSPSettings{}, true},
+		"zero period":     {map[string]string{"ingress.kubernetes.io/ocsp-period": "0s"}, engine.OCSPSettings{}, true},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+			ocsp, err := CreateOCSPSettings(ingress, defaults)
+			if test.err {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(ocsp, test.expected) {
+				t.Errorf("Unexpected settings %+v, expected %+v", ocsp, test.expected)
+			}
+		})
+	}
+}
+
+func TestValidateOCSPSettings(t *testing.T) {
+	if err := ValidateOCSPSettings(engine.OCSPSettings{}); err != nil {
+		t.Errorf("Unexpected error for the default period: %s", err)
+	}
+	if err := ValidateOCSPSettings(engine.OCSPSettings{Period: "-1h"}); err == nil {
+		t.Error("Expected an error for a negative period")
+	}
+}
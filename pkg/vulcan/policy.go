@@ -0,0 +1,78 @@
+package vulcan
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	"github.com/ghodss/yaml"
+	"github.com/vulcand/vulcand/engine"
+)
+
+// HTTPSListener is the ID of the HTTPS listener managed by the controller.
+const HTTPSListener = "vulcand-ingress-https"
+
+// TLSPolicy is the TLS configuration required of every HTTPS endpoint the
+// controller manages. Empty fields fall back to the vulcand defaults.
+//
+// vulcand applies TLS settings per listener, not per host, so the policy holds
+// for every host alike and can't be changed by a single ingress.
+type TLSPolicy struct {
+	// MinVersion is the minimum TLS version, for example VersionTLS12.
+	MinVersion string `json:"minVersion,omitempty"`
+	// CipherSuites are the approved cipher suites, for example
+	// TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256.
+	CipherSuites []string `json:"cipherSuites,omitempty"`
+}
+
+// LoadTLSPolicy reads a TLS policy from a YAML or JSON file.
+func LoadTLSPolicy(filename string) (*TLSPolicy, error) {
+	data, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	policy := &TLSPolicy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid TLS policy %s: %s", filename, err)
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid TLS policy %s: %s", filename, err)
+	}
+	return policy, nil
+}
+
+// Validate checks that vulcand knows the version and cipher suites of the
+// policy.
+func (p *TLSPolicy) Validate() error {
+	settings := p.Settings()
+	_, err := engine.NewTLSConfig(&settings)
+	return err
+}
+
+// Settings returns the vulcand TLS settings enforcing the policy. A nil policy
+// leaves everything to vulcand.
+func (p *TLSPolicy) Settings() engine.TLSSettings {
+	if p == nil {
+		return engine.TLSSettings{}
+	}
+	return engine.TLSSettings{
+		MinVersion:               p.MinVersion,
+		CipherSuites:             p.CipherSuites,
+		PreferServerCipherSuites: len(p.CipherSuites) > 0,
+	}
+}
+
+// CreateHTTPSListener returns the HTTPS listener at the address, enforcing the
+// TLS policy.
+func CreateHTTPSListener(address string, policy *TLSPolicy) engine.Listener {
+	return engine.Listener{
+		Id:       HTTPSListener,
+		Protocol: engine.HTTPS,
+		Address: engine.Address{
+			Network: "tcp",
+			Address: address,
+		},
+		Settings: &engine.HTTPSListenerSettings{
+			TLS: policy.Settings(),
+		},
+	}
+}
@@ -0,0 +1,61 @@
+package vulcan
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+func TestLoadTLSPolicy(t *testing.T) {
+	for name, test := range map[string]struct {
+		data     string
+		expected *TLSPolicy
+	}{
+		"yaml": {"minVersion: VersionTLS12\ncipherSuites:\n- TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256\n", &TLSPolicy{
+			MinVersion:   "VersionTLS12",
+			CipherSuites: []string{"TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"},
+		}},
+		"json":            {`{"minVersion": "VersionTLS11"}`, &TLSPolicy{MinVersion: "VersionTLS11"}},
+		"invalid version": {"minVersion: SSLv3\n", nil},
+		"invalid cipher":  {"cipherSuites: [TLS_NULL]\n", nil},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, err := ioutil.TempFile("", "policy")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.Remove(f.Name())
+			if _, err := f.WriteString(test.data); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			policy, err := LoadTLSPolicy(f.Name())
+			if test.expected == nil {
+				if err == nil {
+					t.Error("Expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(policy, test.expected) {
+				t.Errorf("Unexpected policy %+v, expected %+v", policy, test.expected)
+			}
+		})
+	}
+}
+
+func TestCreateHTTPSListener(t *testing.T) {
+	listener := CreateHTTPSListener("0.0.0.0:443", &TLSPolicy{MinVersion: "VersionTLS12"})
+	if listener.Protocol != engine.HTTPS || listener.Settings.TLS.MinVersion != "VersionTLS12" {
+		t.Errorf("Unexpected listener %+v", listener)
+	}
+	if _, err := listener.TLSConfig(); err != nil {
+		t.Errorf("Unexpected invalid listener TLS settings: %s", err)
+	}
+}
@@ -2,9 +2,20 @@ package vulcan
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 )
 
+// Path types of networking.k8s.io/v1 ingresses.
+const (
+	PathTypeExact                  = "Exact"
+	PathTypePrefix                 = "Prefix"
+	PathTypeImplementationSpecific = "ImplementationSpecific"
+)
+
+// CreateRoute creates a vulcand route expression matching the host and path.
+// Paths are regular expressions, which match anywhere in the request path
+// unless they are anchored.
 func CreateRoute(host, path string) string {
 
 	exp := make([]string, 0, 2)
@@ -19,3 +30,25 @@ func CreateRoute(host, path string) string {
 
 	return strings.Join(exp, " && ")
 }
+
+// CreatePathRegexp translates an ingress path of the given type into the
+// regular expression matching the same request paths.
+//
+// Exact paths match only themselves. Prefix paths match themselves and any
+// path below them, element by element, so that /foo matches /foo/bar but not
+// /foobar. Implementation specific paths are regular expressions, as they
+// always have been for this controller.
+func CreatePathRegexp(path, pathType string) string {
+	switch pathType {
+	case PathTypeExact:
+		return "^" + regexp.QuoteMeta(path) + "$"
+	case PathTypePrefix:
+		prefix := strings.TrimRight(path, "/")
+		if prefix == "" {
+			return "^/"
+		}
+		return "^" + regexp.QuoteMeta(prefix) + "(/.*)?$"
+	default:
+		return path
+	}
+}
@@ -1,6 +1,9 @@
 package vulcan
 
-import "testing"
+import (
+	"regexp"
+	"testing"
+)
 
 func TestCreateRoute(t *testing.T) {
 	for expected, test := range map[string]struct {
@@ -8,6 +11,7 @@ func TestCreateRoute(t *testing.T) {
 		path string
 	}{
 		"PathRegexp(`/hello`)":                        {"", "/hello"},
+		"PathRegexp(`^/hello$`)":                      {"", "^/hello$"},
 		"Host(`example.com`)":                         {"example.com", ""},
 		"Host(`example.com`) && PathRegexp(`/hello`)": {"example.com", "/hello"},
 	} {
@@ -17,3 +21,33 @@ func TestCreateRoute(t *testing.T) {
 		}
 	}
 }
+
+func TestCreatePathRegexp(t *testing.T) {
+	for _, test := range []struct {
+		path     string
+		pathType string
+		match    []string
+		noMatch  []string
+	}{
+		{"/foo", PathTypeExact, []string{"/foo"}, []string{"/foo/", "/foo/bar", "/foobar", "/bar/foo"}},
+		{"/foo.bar", PathTypeExact, []string{"/foo.bar"}, []string{"/fooxbar"}},
+		{"/foo", PathTypePrefix, []string{"/foo", "/foo/", "/foo/bar"}, []string{"/foobar", "/bar/foo"}},
+		{"/foo/", PathTypePrefix, []string{"/foo", "/foo/", "/foo/bar"}, []string{"/foobar"}},
+		{"/", PathTypePrefix, []string{"/", "/foo"}, []string{}},
+		{"/foo/[0-9]+", PathTypeImplementationSpecific, []string{"/foo/1", "/foo/12/bar", "/bar/foo/1"}, []string{"/foo/bar"}},
+		{"/foo", "", []string{"/foo", "/foobar", "/bar/foo"}, []string{"/bar"}},
+		{"^/foo", "", []string{"/foo", "/foobar"}, []string{"/bar/foo"}},
+	} {
+		exp := regexp.MustCompile(CreatePathRegexp(test.path, test.pathType))
+		for _, path := range test.match {
+			if !exp.MatchString(path) {
+				t.Errorf("Expected %s path %q to match %q", test.pathType, test.path, path)
+			}
+		}
+		for _, path := range test.noMatch {
+			if exp.MatchString(path) {
+				t.Errorf("Expected %s path %q not to match %q", test.pathType, test.path, path)
+			}
+		}
+	}
+}
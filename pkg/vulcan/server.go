@@ -0,0 +1,118 @@
+package vulcan
+
+import (
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+// CreateServers returns a vulcand server for every ready address of the
+// endpoints that exposes the given service port. Addresses that are not ready
+// are left out so that vulcand only balances traffic onto pods that can serve
+// it, unless waiting reports that the pod only waits for its registration with
+// vulcand to become ready. waiting may be nil.
+func CreateServers(endpoints *v1.Endpoints, port v1.ServicePort, scheme string, waiting func(v1.EndpointAddress) bool) []engine.Server {
+	var servers []engine.Server
+	if endpoints == nil {
+		return servers
+	}
+	for _, subset := range endpoints.Subsets {
+		p, ok := matchPort(subset.Ports, port)
+		if !ok {
+			continue
+		}
+		addresses := subset.Addresses
+		if waiting != nil {
+			for _, address := range subset.NotReadyAddresses {
+				if waiting(address) {
+					addresses = append(addresses[:len(addresses):len(addresses)], address)
+				}
+			}
+		}
+		for _, address := range addresses {
+			servers = append(servers, engine.Server{
+				Id:  CreateServerID(address),
+				URL: CreateURL(scheme, address.IP, p.Port),
+			})
+		}
+	}
+	return servers
+}
+
+// UnreadyServerIDs returns the IDs of the servers of the endpoints which are
+// left out by CreateServers because their pods are not ready. Unlike servers
+// of pods which are gone, they are deleted without delay.
+func UnreadyServerIDs(endpoints *v1.Endpoints, port v1.ServicePort, waiting func(v1.EndpointAddress) bool) []string {
+	var ids []string
+	if endpoints == nil {
+		return ids
+	}
+	for _, subset := range endpoints.Subsets {
+		if _, ok := matchPort(subset.Ports, port); !ok {
+			continue
+		}
+		for _, address := range subset.NotReadyAddresses {
+			if waiting == nil || !waiting(address) {
+				ids = append(ids, CreateServerID(address))
+			}
+		}
+	}
+	return ids
+}
+
+// CreateExternalServer returns the vulcand server of an ExternalName service,
+// which is the external host itself. A numeric backend port is used as is,
+// since such services usually don't list any ports, while a named one must be
+// defined by the service.
+func CreateExternalServer(service *v1.Service, port intstr.IntOrString, scheme string) (engine.Server, bool) {
+	number := port.IntVal
+	if port.Type == intstr.String {
+		p, ok := FindServicePort(service, port)
+		if !ok {
+			return engine.Server{}, false
+		}
+		number = p.Port
+	}
+	return engine.Server{
+		Id:  service.Spec.ExternalName,
+		URL: CreateURL(scheme, service.Spec.ExternalName, number),
+	}, true
+}
+
+// CreateServerID returns the ID of the vulcand server backed by the endpoint
+// address. The name of the pod is preferred as it is stable while the pod
+// exists, otherwise the address IP is used.
+func CreateServerID(address v1.EndpointAddress) string {
+	if ref := address.TargetRef; ref != nil && ref.Kind == "Pod" && ref.Name != "" {
+		return ref.Name
+	}
+	return address.IP
+}
+
+// FindServicePort finds the port of the service referenced by an ingress
+// backend, either by its name or its number.
+func FindServicePort(service *v1.Service, port intstr.IntOrString) (v1.ServicePort, bool) {
+	for _, p := range service.Spec.Ports {
+		if port.Type == intstr.String && p.Name == port.StrVal {
+			return p, true
+		}
+		if port.Type == intstr.Int && p.Port == port.IntVal {
+			return p, true
+		}
+	}
+	return v1.ServicePort{}, false
+}
+
+// matchPort finds the endpoint port which corresponds to the service port.
+// Endpoint ports carry the name of the service port they belong to, which
+// resolves the target port of the service to a number even if it refers to a
+// named container port.
+func matchPort(ports []v1.EndpointPort, port v1.ServicePort) (v1.EndpointPort, bool) {
+	for _, p := range ports {
+		if p.Name == port.Name {
+			return p, true
+		}
+	}
+	return v1.EndpointPort{}, false
+}
@@ -0,0 +1,179 @@
+package vulcan
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+func TestCreateServers(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{
+					{IP: "10.0.0.1", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-1"}},
+					{IP: "10.0.0.2"},
+				},
+				NotReadyAddresses: []v1.EndpointAddress{
+					{IP: "10.0.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-3"}},
+				},
+				Ports: []v1.EndpointPort{
+					{Name: "http", Port: 8080},
+					{Name: "metrics", Port: 9090},
+				},
+			},
+		},
+	}
+
+	for name, test := range map[string]struct {
+		port     v1.ServicePort
+		expected map[string]string
+	}{
+		"http": {v1.ServicePort{Name: "http", Port: 80}, map[string]string{
+			"foo-1":    "http://10.0.0.1:8080",
+			"10.0.0.2": "http://10.0.0.2:8080",
+		}},
+		"metrics": {v1.ServicePort{Name: "metrics", Port: 9090}, map[string]string{
+			"foo-1":    "http://10.0.0.1:9090",
+			"10.0.0.2": "http://10.0.0.2:9090",
+		}},
+		"unknown": {v1.ServicePort{Name: "grpc", Port: 50051}, map[string]string{}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			servers := CreateServers(endpoints, test.port, "http", nil)
+			if len(servers) != len(test.expected) {
+				t.Fatalf("Unexpected number of servers %d, expected %d", len(servers), len(test.expected))
+			}
+			for _, server := range servers {
+				if test.expected[server.Id] != server.URL {
+					t.Errorf("Unexpected URL %q for server %q", server.URL, server.Id)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateServersUnnamedPort(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}},
+				Ports:     []v1.EndpointPort{{Port: 8080}},
+			},
+		},
+	}
+	// The only port of a service may be unnamed, in which case its endpoint
+	// port is unnamed as well.
+	servers := CreateServers(endpoints, v1.ServicePort{Port: 80, TargetPort: intstr.FromInt(8080)}, "http", nil)
+	if len(servers) != 1 || servers[0].URL != "http://10.0.0.1:8080" {
+		t.Errorf("Unexpected servers %v", servers)
+	}
+}
+
+func TestCreateServersWaiting(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}},
+				NotReadyAddresses: []v1.EndpointAddress{
+					{IP: "10.0.0.2", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-2"}},
+					{IP: "10.0.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-3"}},
+				},
+				Ports: []v1.EndpointPort{{Port: 8080}},
+			},
+		},
+	}
+	waiting := func(address v1.EndpointAddress) bool {
+		return address.TargetRef != nil && address.TargetRef.Name == "foo-2"
+	}
+	servers := CreateServers(endpoints, v1.ServicePort{Port: 80}, "http", waiting)
+	if len(servers) != 2 || servers[0].Id != "10.0.0.1" || servers[1].Id != "foo-2" {
+		t.Errorf("Unexpected servers %v", servers)
+	}
+	if len(endpoints.Subsets[0].Addresses) != 1 {
+		t.Errorf("Expected endpoints not to be modified, got %v", endpoints.Subsets[0].Addresses)
+	}
+}
+
+func TestFindServicePort(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Ports: []v1.ServicePort{
+				{Name: "http", Port: 80, TargetPort: intstr.FromString("web")},
+				{Name: "metrics", Port: 9090, TargetPort: intstr.FromInt(9090)},
+			},
+		},
+	}
+
+	for _, test := range []struct {
+		port     intstr.IntOrString
+		expected string
+		ok       bool
+	}{
+		{intstr.FromString("http"), "http", true},
+		{intstr.FromInt(80), "http", true},
+		{intstr.FromInt(9090), "metrics", true},
+		{intstr.FromInt(8080), "", false},
+		{intstr.FromString("web"), "", false},
+	} {
+		port, ok := FindServicePort(service, test.port)
+		if ok != test.ok || port.Name != test.expected {
+			t.Errorf("Unexpected port %q (%t) for %s, expected %q", port.Name, ok, test.port.String(), test.expected)
+		}
+	}
+}
+
+func TestCreateExternalServer(t *testing.T) {
+	service := &v1.Service{
+		Spec: v1.ServiceSpec{
+			Type:         v1.ServiceTypeExternalName,
+			ExternalName: "api.example.com",
+			Ports:        []v1.ServicePort{{Name: "https", Port: 443}},
+		},
+	}
+
+	for _, test := range []struct {
+		port     intstr.IntOrString
+		scheme   string
+		expected string
+		ok       bool
+	}{
+		{intstr.FromInt(8080), "http", "http://api.example.com:8080", true},
+		{intstr.FromString("https"), "https", "https://api.example.com:443", true},
+		{intstr.FromString("http"), "http", "", false},
+	} {
+		server, ok := CreateExternalServer(service, test.port, test.scheme)
+		if ok != test.ok || server.URL != test.expected {
+			t.Errorf("Unexpected server %+v (%t) for port %s", server, ok, test.port.String())
+		}
+		if ok && server.Id != "api.example.com" {
+			t.Errorf("Unexpected server ID %q", server.Id)
+		}
+	}
+}
+
+func TestUnreadyServerIDs(t *testing.T) {
+	endpoints := &v1.Endpoints{
+		Subsets: []v1.EndpointSubset{
+			{
+				Addresses: []v1.EndpointAddress{{IP: "10.0.0.1"}},
+				NotReadyAddresses: []v1.EndpointAddress{
+					{IP: "10.0.0.2", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-2"}},
+					{IP: "10.0.0.3", TargetRef: &v1.ObjectReference{Kind: "Pod", Name: "foo-3"}},
+				},
+				Ports: []v1.EndpointPort{{Port: 8080}},
+			},
+		},
+	}
+	waiting := func(address v1.EndpointAddress) bool {
+		return address.TargetRef != nil && address.TargetRef.Name == "foo-2"
+	}
+	if ids := UnreadyServerIDs(endpoints, v1.ServicePort{Port: 80}, waiting); !reflect.DeepEqual(ids, []string{"foo-3"}) {
+		t.Errorf("Unexpected unready servers %v", ids)
+	}
+	if ids := UnreadyServerIDs(endpoints, v1.ServicePort{Port: 80}, nil); !reflect.DeepEqual(ids, []string{"foo-2", "foo-3"}) {
+		t.Errorf("Unexpected unready servers %v", ids)
+	}
+}
@@ -0,0 +1,341 @@
+package vulcan
+
+import (
+	"time"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+// State is a set of vulcand objects. It is used to describe both the objects
+// an ingress should produce and the objects vulcand currently holds for it.
+type State struct {
+	Hosts       []engine.Host
+	Backends    []Backend
+	Frontends   []engine.Frontend
+	Middlewares []Middleware
+}
+
+// Backend is a vulcand backend together with its servers.
+type Backend struct {
+	engine.Backend
+	Servers []engine.Server
+	// Unready are the IDs of servers whose pods are known but not ready.
+	Unready []string
+	// Shared backends are keyed by service port rather than by ingress, and
+	// used by every ingress routing to that port.
+	Shared bool
+}
+
+// Middleware is a vulcand middleware together with the frontend it is
+// attached to.
+type Middleware struct {
+	engine.Middleware
+	FrontendKey engine.FrontendKey
+}
+
+func (m Middleware) Key() engine.MiddlewareKey {
+	return engine.MiddlewareKey{FrontendKey: m.FrontendKey, Id: m.Id}
+}
+
+// AddHost adds a host to the state, replacing any host with the same name.
+func (s *State) AddHost(host engine.Host) {
+	for i := range s.Hosts {
+		if s.Hosts[i].Name == host.Name {
+			s.Hosts[i] = host
+			return
+		}
+	}
+	s.Hosts = append(s.Hosts, host)
+}
+
+// AddBackend adds a backend to the state, replacing any backend with the same
+// ID.
+func (s *State) AddBackend(backend Backend) {
+	for i := range s.Backends {
+		if s.Backends[i].Id == backend.Id {
+			s.Backends[i] = backend
+			return
+		}
+	}
+	s.Backends = append(s.Backends, backend)
+}
+
+// AddFrontend adds a frontend to the state, replacing any frontend with the
+// same ID.
+func (s *State) AddFrontend(frontend engine.Frontend) {
+	for i := range s.Frontends {
+		if s.Frontends[i].Id == frontend.Id {
+			s.Frontends[i] = frontend
+			return
+		}
+	}
+	s.Frontends = append(s.Frontends, frontend)
+}
+
+// AddMiddleware adds a middleware to the state, replacing any middleware with
+// the same key.
+func (s *State) AddMiddleware(middleware Middleware) {
+	for i := range s.Middlewares {
+		if s.Middlewares[i].Key() == middleware.Key() {
+			s.Middlewares[i] = middleware
+			return
+		}
+	}
+	s.Middlewares = append(s.Middlewares, middleware)
+}
+
+// HasHost reports whether the state holds a host with the given name.
+func (s *State) HasHost(name string) bool {
+	for _, host := range s.Hosts {
+		if host.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// HasBackend reports whether the state holds a backend with the given ID.
+func (s *State) HasBackend(id string) bool {
+	for _, backend := range s.Backends {
+		if backend.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HasFrontend reports whether the state holds a frontend with the given ID.
+func (s *State) HasFrontend(id string) bool {
+	for _, frontend := range s.Frontends {
+		if frontend.Id == id {
+			return true
+		}
+	}
+	return false
+}
+
+// HasMiddleware reports whether the state holds a middleware with the given
+// key.
+func (s *State) HasMiddleware(key engine.MiddlewareKey) bool {
+	for _, middleware := range s.Middlewares {
+		if middleware.Key() == key {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge adds all objects of the other state to this state.
+func (s *State) Merge(other *State) {
+	for _, host := range other.Hosts {
+		s.AddHost(host)
+	}
+	for _, backend := range other.Backends {
+		s.AddBackend(backend)
+	}
+	for _, frontend := range other.Frontends {
+		s.AddFrontend(frontend)
+	}
+	for _, middleware := range other.Middlewares {
+		s.AddMiddleware(middleware)
+	}
+}
+
+// Empty reports whether the state holds no objects.
+func (s *State) Empty() bool {
+	return len(s.Hosts) == 0 &&
+		len(s.Backends) == 0 &&
+		len(s.Frontends) == 0 &&
+		len(s.Middlewares) == 0
+}
+
+// Stale returns the objects of the current state which are not part of the
+// desired state.
+func Stale(current, desired *State) *State {
+
+	hosts := make(map[string]bool)
+	for _, host := range desired.Hosts {
+		hosts[host.Name] = true
+	}
+
+	backends := make(map[string]bool)
+	for _, backend := range desired.Backends {
+		backends[backend.Id] = true
+	}
+
+	frontends := make(map[string]bool)
+	for _, frontend := range desired.Frontends {
+		frontends[frontend.Id] = true
+	}
+
+	middlewares := make(map[engine.MiddlewareKey]bool)
+	for _, middleware := range desired.Middlewares {
+		middlewares[middleware.Key()] = true
+	}
+
+	stale := &State{}
+
+	for _, host := range current.Hosts {
+		if !hosts[host.Name] {
+			stale.AddHost(host)
+		}
+	}
+	for _, backend := range current.Backends {
+		if !backends[backend.Id] {
+			stale.AddBackend(backend)
+		}
+	}
+	for _, frontend := range current.Frontends {
+		if !frontends[frontend.Id] {
+			stale.AddFrontend(frontend)
+		}
+	}
+	for _, middleware := range current.Middlewares {
+		// Middlewares of stale frontends are removed together with their
+		// frontend, so they are listed as well.
+		if !middlewares[middleware.Key()] {
+			stale.AddMiddleware(middleware)
+		}
+	}
+
+	return stale
+}
+
+// Sync upserts every object of the state into vulcand. Objects are upserted in
+// dependency order: hosts and backends first, so that frontends never
+// reference a missing backend, and middlewares last.
+func (c *Client) Sync(state *State) error {
+
+	for _, host := range state.Hosts {
+		if err := c.UpsertHost(host); err != nil {
+			return err
+		}
+	}
+
+	for _, backend := range state.Backends {
+		if err := c.UpsertBackend(backend.Backend); err != nil {
+			return err
+		}
+		if err := c.SyncServers(backend.Key(), backend.Servers, backend.Unready); err != nil {
+			return err
+		}
+	}
+
+	if err := c.SyncFrontends(state); err != nil {
+		return err
+	}
+
+	for _, middleware := range state.Middlewares {
+		if err := c.UpsertMiddleware(middleware.FrontendKey, middleware.Middleware, time.Duration(0)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// SyncFrontends upserts the frontends of the state into vulcand. vulcand routes
+// requests by route expression rather than frontend, so deleting a frontend
+// also unroutes any other frontend with the same route. Frontends which replace
+// another frontend with the same route should be synced again after the old
+// one is deleted.
+func (c *Client) SyncFrontends(state *State) error {
+	for _, frontend := range state.Frontends {
+		if err := c.UpsertFrontend(frontend, time.Duration(0)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete removes every object of the state from vulcand in the reverse order
+// of Sync, so that no frontend is ever left pointing at a deleted backend.
+// Objects which are already gone are skipped.
+func (c *Client) Delete(state *State) error {
+
+	for _, middleware := range state.Middlewares {
+		if err := c.DeleteMiddleware(middleware.Key()); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	for _, frontend := range state.Frontends {
+		if err := c.DeleteFrontend(frontend.Key()); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	for _, backend := range state.Backends {
+		// Servers have to be removed before their backend can be deleted.
+		// Nothing routes to the backend anymore, so there is no point in
+		// delaying their deletion.
+		if err := c.syncServers(backend.Key(), nil, nil, 0); ignoreNotFound(err) != nil {
+			return err
+		}
+		if err := c.DeleteBackend(backend.Key()); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	for _, host := range state.Hosts {
+		if err := c.DeleteHost(host.Key()); ignoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func ignoreNotFound(err error) error {
+	if _, ok := err.(*engine.NotFoundError); ok {
+		return nil
+	}
+	return err
+}
+
+// List returns every host, frontend, backend and middleware vulcand currently
+// holds, regardless of who created them.
+func (c *Client) List() (*State, error) {
+
+	state := &State{}
+
+	hosts, err := c.GetHosts()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, host := range hosts {
+		state.AddHost(host)
+	}
+
+	frontends, err := c.GetFrontends()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, frontend := range frontends {
+		state.AddFrontend(frontend)
+
+		middlewares, err := c.GetMiddlewares(frontend.Key())
+		if err != nil {
+			return nil, err
+		}
+		for _, middleware := range middlewares {
+			state.AddMiddleware(Middleware{
+				Middleware:  middleware,
+				FrontendKey: frontend.Key(),
+			})
+		}
+	}
+
+	backends, err := c.GetBackends()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, backend := range backends {
+		state.AddBackend(Backend{Backend: backend})
+	}
+
+	return state, nil
+}
@@ -0,0 +1,66 @@
+package vulcan
+
+import (
+	"testing"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+func TestStale(t *testing.T) {
+	current := &State{
+		Hosts: []engine.Host{{Name: "example.com"}},
+		Backends: []Backend{
+			{Backend: engine.Backend{Id: "ns.ingress.foo"}},
+			{Backend: engine.Backend{Id: "ns.ingress.bar"}},
+		},
+		Frontends: []engine.Frontend{
+			{Id: "ns.ingress.foo"},
+			{Id: "ns.ingress.bar"},
+		},
+		Middlewares: []Middleware{
+			{
+				FrontendKey: engine.FrontendKey{Id: "ns.ingress.foo"},
+				Middleware:  engine.Middleware{Id: "ns.ingress.foo.ratelimit"},
+			},
+			{
+				FrontendKey: engine.FrontendKey{Id: "ns.ingress.foo"},
+				Middleware:  engine.Middleware{Id: "ns.ingress.foo.connlimit"},
+			},
+		},
+	}
+
+	desired := &State{
+		Hosts: []engine.Host{{Name: "example.com"}},
+		Backends: []Backend{
+			{Backend: engine.Backend{Id: "ns.ingress.foo"}},
+		},
+		Frontends: []engine.Frontend{
+			{Id: "ns.ingress.foo"},
+		},
+		Middlewares: []Middleware{
+			{
+				FrontendKey: engine.FrontendKey{Id: "ns.ingress.foo"},
+				Middleware:  engine.Middleware{Id: "ns.ingress.foo.ratelimit"},
+			},
+		},
+	}
+
+	stale := Stale(current, desired)
+
+	if len(stale.Hosts) != 0 {
+		t.Errorf("Unexpected stale hosts %v", stale.Hosts)
+	}
+	if len(stale.Backends) != 1 || stale.Backends[0].Id != "ns.ingress.bar" {
+		t.Errorf("Unexpected stale backends %v", stale.Backends)
+	}
+	if len(stale.Frontends) != 1 || stale.Frontends[0].Id != "ns.ingress.bar" {
+		t.Errorf("Unexpected stale frontends %v", stale.Frontends)
+	}
+	if len(stale.Middlewares) != 1 || stale.Middlewares[0].Id != "ns.ingress.foo.connlimit" {
+		t.Errorf("Unexpected stale middlewares %v", stale.Middlewares)
+	}
+
+	if stale := Stale(desired, desired); !stale.Empty() {
+		t.Errorf("Expected no stale objects, got %v", stale)
+	}
+}
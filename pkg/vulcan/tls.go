@@ -0,0 +1,101 @@
+package vulcan
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/vulcand/vulcand/engine"
+
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/annotations"
+)
+
+// CreateKeyPair builds a vulcand key pair from a secret of type
+// kubernetes.io/tls.
+func CreateKeyPair(secret *v1.Secret) (*engine.KeyPair, error) {
+	if secret.Type != v1.SecretTypeTLS {
+		return nil, fmt.Errorf("secret %s/%s is of type %q, expected %q",
+			secret.Namespace,
+			secret.Name,
+			secret.Type,
+			v1.SecretTypeTLS)
+	}
+	keyPair, err := engine.NewKeyPair(secret.Data[v1.TLSCertKey], secret.Data[v1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("secret %s/%s contains an invalid key pair: %s",
+			secret.Namespace,
+			secret.Name,
+			err)
+	}
+	return keyPair, nil
+}
+
+// DefaultHost is the name of the host holding the default certificate, which
+// vulcand serves to clients whose SNI matches no other host.
+const DefaultHost = "default"
+
+// CreateDefaultHost returns the host holding the default certificate.
+func CreateDefaultHost(keyPair *engine.KeyPair, ocsp engine.OCSPSettings) engine.Host {
+	host := CreateHost(DefaultHost, keyPair, ocsp)
+	host.Settings.Default = true
+	return host
+}
+
+// Covers reports whether the certificate of the key pair is valid for the
+// host, either by name or through a wildcard.
+func Covers(keyPair *engine.KeyPair, host string) bool {
+	block, _ := pem.Decode(keyPair.Cert)
+	if block == nil {
+		return false
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return false
+	}
+	return cert.VerifyHostname(host) == nil
+}
+
+// CreateBackendTLS returns the TLS settings with which vulcand connects to the
+// servers of the backend, or nil if it connects over plain http. Either the
+// ingress or the service may be nil. vulcand verifies the certificates of the
+// servers against the system roots, which certificates issued for pod IPs
+// rarely pass, unless verification is skipped explicitly.
+func CreateBackendTLS(ingress *v1beta1.Ingress, service *v1.Service) *engine.TLSSettings {
+	if CreateScheme(ingress, service) != "https" {
+		return nil
+	}
+	insecure := backendAnnotations(ingress, service)(annotations.BackendInsecureSkipVerify)
+	return &engine.TLSSettings{InsecureSkipVerify: annotations.Bool(insecure)}
+}
+
+// CheckHostTLS rejects ingresses whose annotations ask for a TLS version or
+// cipher suites of their own. vulcand applies TLS settings per listener, so
+// the hosts of a single ingress can't be held to a stricter policy than the
+// others, and serving them with weaker settings than asked for would quietly
+// weaken what was asked for.
+func CheckHostTLS(ingress *v1beta1.Ingress) error {
+	for _, a := range []string{annotations.TLSMinVersion, annotations.TLSCipherSuites} {
+		if _, ok := ingress.Annotations[a]; ok {
+			return fmt.Errorf("annotation %s is not supported, vulcand applies TLS settings to all hosts alike, so the TLS hosts of the ingress are skipped", a)
+		}
+	}
+	return nil
+}
+
+// CheckBackendTLS rejects backends whose annotations ask vulcand to verify
+// their servers against a CA bundle from a secret, or to present a client
+// certificate from a secret. The backend TLS settings of the vulcand API have
+// no room for either, and connecting without them would quietly weaken what
+// was asked for.
+func CheckBackendTLS(ingress *v1beta1.Ingress, service *v1.Service) error {
+	lookup := backendAnnotations(ingress, service)
+	for _, a := range []string{annotations.BackendCASecret, annotations.BackendClientSecret} {
+		if secret := lookup(a); secret != "" {
+			return fmt.Errorf("annotation %s references secret %s, but vulcand backends can't be given a CA bundle or client certificate", a, secret)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package vulcan
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"k8s.io/api/core/v1"
+	"k8s.io/api/extensions/v1beta1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/vulcand/vulcand/engine"
+)
+
+func generateKeyPair(t *testing.T, hosts ...string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "example.com"},
+		DNSNames:     hosts,
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestCreateKeyPair(t *testing.T) {
+	cert, key := generateKeyPair(t, "example.com")
+
+	for name, test := range map[string]struct {
+		secret *v1.Secret
+		valid  bool
+	}{
+		"valid": {&v1.Secret{
+			Type: v1.SecretTypeTLS,
+			Data: map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key},
+		}, true},
+		"opaque": {&v1.Secret{
+			Type: v1.SecretTypeOpaque,
+			Data: map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key},
+		}, false},
+		"missing key": {&v1.Secret{
+			Type: v1.SecretTypeTLS,
+			Data: map[string][]byte{v1.TLSCertKey: cert},
+		}, false},
+		"mismatched": {&v1.Secret{
+			Type: v1.SecretTypeTLS,
+			Data: map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: []byte("invalid")},
+		}, false},
+	} {
+		t.Run(name, func(t *testing.T) {
+			keyPair, err := CreateKeyPair(test.secret)
+			if test.valid && err != nil {
+				t.Errorf("Unexpected error %s", err)
+			}
+			if !test.valid && err == nil {
+				t.Error("Expected an error")
+			}
+			if test.valid && string(keyPair.Cert) != string(cert) {
+				t.Error("Unexpected certificate")
+			}
+		})
+	}
+}
+
+func TestCovers(t *testing.T) {
+	cert, key := generateKeyPair(t, "*.example.com", "example.com")
+	keyPair, err := CreateKeyPair(&v1.Secret{
+		Type: v1.SecretTypeTLS,
+		Data: map[string][]byte{v1.TLSCertKey: cert, v1.TLSPrivateKeyKey: key},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for host, expected := range map[string]bool{
+		"example.com":         true,
+		"api.example.com":     true,
+		"foo.api.example.com": false,
+		"example.org":         false,
+	} {
+		if covers := Covers(keyPair, host); covers != expected {
+			t.Errorf("Unexpected coverage %t of host %q", covers, host)
+		}
+	}
+}
+
+func TestCreateDefaultHost(t *testing.T) {
+	host := CreateDefaultHost(&engine.KeyPair{}, engine.OCSPSettings{Enabled: true})
+	if host.Name != DefaultHost || !host.Settings.Default || host.Settings.KeyPair == nil || !host.Settings.OCSP.Enabled {
+		t.Errorf("Unexpected default host %+v", host)
+	}
+}
+
+func TestCreateBackendTLS(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"ingress.kubernetes.io/backend-protocol": "HTTPS"},
+		},
+	}
+	if tls := CreateBackendTLS(ingress, nil); tls == nil || tls.InsecureSkipVerify {
+		t.Errorf("Expected verifying TLS settings for an https backend, got %+v", tls)
+	}
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{"ingress.kubernetes.io/backend-insecure-skip-verify": "true"},
+		},
+	}
+	if tls := CreateBackendTLS(ingress, service); tls == nil || !tls.InsecureSkipVerify {
+		t.Errorf("Expected TLS settings skipping verification, got %+v", tls)
+	}
+	if CreateBackendTLS(&v1beta1.Ingress{}, nil) != nil {
+		t.Error("Unexpected TLS settings for an http backend")
+	}
+}
+
+func TestCheckBackendTLS(t *testing.T) {
+	for _, test := range []struct {
+		annotations map[string]string
+		err         bool
+	}{
+		{map[string]string{"ingress.kubernetes.io/backend-protocol": "HTTPS"}, false},
+		{map[string]string{"ingress.kubernetes.io/backend-ca-secret": "ca"}, true},
+		{map[string]string{"ingress.kubernetes.io/backend-client-secret": "client"}, true},
+	} {
+		service := &v1.Service{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		if err := CheckBackendTLS(nil, service); (err != nil) != test.err {
+			t.Errorf("Unexpected error %v for annotations %v", err, test.annotations)
+		}
+	}
+}
+
+func TestCheckHostTLS(t *testing.T) {
+	for _, test := range []struct {
+		annotations map[string]string
+		err         bool
+	}{
+		{nil, false},
+		{map[string]string{"ingress.kubernetes.io/ocsp": "true"}, false},
+		{map[string]string{"ingress.kubernetes.io/tls-min-version": "VersionTLS12"}, true},
+		{map[string]string{"ingress.kubernetes.io/tls-cipher-suites": "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256"}, true},
+	} {
+		ingress := &v1beta1.Ingress{ObjectMeta: metav1.ObjectMeta{Annotations: test.annotations}}
+		if err := CheckHostTLS(ingress); (err != nil) != test.err {
+			t.Errorf("Unexpected error %v for annotations %v", err, test.annotations)
+		}
+	}
+}
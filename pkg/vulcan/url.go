@@ -2,13 +2,26 @@ package vulcan
 
 import (
 	"fmt"
+	"net"
+	"strconv"
+	"strings"
 
+	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
+
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/annotations"
 )
 
-func CreateURL(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend) string {
-	return fmt.Sprintf("http://%s.%s:%s",
-		backend.ServiceName,
-		ingress.Namespace,
-		backend.ServicePort.String())
+func CreateURL(scheme, host string, port int32) string {
+	return fmt.Sprintf("%s://%s", scheme, net.JoinHostPort(host, strconv.Itoa(int(port))))
+}
+
+// CreateScheme returns the scheme with which vulcand connects to the servers
+// of the ingress backend. It is http unless the backend protocol annotation of
+// the service, or else the ingress, asks for https. Either may be nil.
+func CreateScheme(ingress *v1beta1.Ingress, service *v1.Service) string {
+	if strings.EqualFold(backendAnnotations(ingress, service)(annotations.BackendProtocol), "https") {
+		return "https"
+	}
+	return "http"
 }
@@ -3,31 +3,59 @@ package vulcan
 import (
 	"testing"
 
+	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/yieldr/vulcand-ingress/pkg/kubernetes/annotations"
 )
 
 func TestCreateURL(t *testing.T) {
+	for expected, test := range map[string]struct {
+		scheme string
+		host   string
+		port   int32
+	}{
+		"http://10.0.0.1:80":          {"http", "10.0.0.1", 80},
+		"https://10.0.0.2:8443":       {"https", "10.0.0.2", 8443},
+		"http://[fd00::1]:8080":       {"http", "fd00::1", 8080},
+		"https://api.example.com:443": {"https", "api.example.com", 443},
+	} {
+		url := CreateURL(test.scheme, test.host, test.port)
+		if url != expected {
+			t.Errorf("Unexpected URL %q from scheme %q, host %q and port %d", url, test.scheme, test.host, test.port)
+		}
+	}
+}
+
+func TestCreateScheme(t *testing.T) {
+	for protocol, expected := range map[string]string{
+		"":      "http",
+		"http":  "http",
+		"HTTPS": "https",
+		"grpc":  "http",
+	} {
+		ingress := &v1beta1.Ingress{
+			ObjectMeta: metav1.ObjectMeta{
+				Annotations: map[string]string{annotations.BackendProtocol: protocol},
+			},
+		}
+		if scheme := CreateScheme(ingress, nil); scheme != expected {
+			t.Errorf("Unexpected scheme %q for protocol %q, expected %q", scheme, protocol, expected)
+		}
+	}
+
 	ingress := &v1beta1.Ingress{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "ingress",
-			Namespace: "namespace",
+			Annotations: map[string]string{annotations.BackendProtocol: "http"},
 		},
 	}
-	for expected, backend := range map[string]*v1beta1.IngressBackend{
-		"http://foo.namespace:80": &v1beta1.IngressBackend{
-			ServiceName: "foo",
-			ServicePort: intstr.FromInt(80),
-		},
-		"http://bar.namespace:443": &v1beta1.IngressBackend{
-			ServiceName: "bar",
-			ServicePort: intstr.FromString("443"),
+	service := &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Annotations: map[string]string{annotations.BackendProtocol: "https"},
 		},
-	} {
-		url := CreateURL(ingress, backend)
-		if url != expected {
-			t.Errorf("Unexpected URL %q from service %q and port %q", url, backend.ServiceName, backend.ServicePort)
-		}
+	}
+	if scheme := CreateScheme(ingress, service); scheme != "https" {
+		t.Errorf("Expected the service protocol to take precedence, got %q", scheme)
 	}
 }
@@ -1,11 +1,20 @@
 package vulcan
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 
+	"github.com/sirupsen/logrus"
 	"github.com/vulcand/vulcand/api"
 	"github.com/vulcand/vulcand/engine"
 	"github.com/yieldr/vulcand/registry"
@@ -15,17 +24,35 @@ import (
 
 type Client struct {
 	*api.Client
+
+	deletionDelay time.Duration
+	logger        *logrus.Logger
+
+	deletingMu sync.Mutex
+	deleting   map[engine.ServerKey]time.Time
 }
 
-func New(addr string) *Client {
+// New returns a client of the vulcand API at addr. Servers removed from a
+// backend because their pod is gone are only deleted after the deletion delay.
+//
+// vulcand has no way of taking a server out of rotation, so until then it
+// keeps receiving new requests as well as finishing those in flight. The
+// delay is only useful if the pod keeps serving for at least as long after it
+// started terminating.
+func New(addr string, deletionDelay time.Duration, logger *logrus.Logger) *Client {
 	r, _ := registry.GetRegistry()
-	return &Client{api.NewClient(addr, r)}
+	return &Client{
+		Client:        api.NewClient(addr, r),
+		deletionDelay: deletionDelay,
+		logger:        logger,
+		deleting:      make(map[engine.ServerKey]time.Time),
+	}
 }
 
-func (c *Client) SyncFrontend(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend, host, path string) error {
-	return c.UpsertFrontend(engine.Frontend{
-		Id:        CreateID(ingress, backend),
-		BackendId: CreateID(ingress, backend),
+func CreateFrontend(ingress *v1beta1.Ingress, backendID, host, path string) engine.Frontend {
+	return engine.Frontend{
+		Id:        CreateFrontendID(ingress, host, path),
+		BackendId: backendID,
 		Type:      engine.HTTP,
 		Route:     CreateRoute(host, path),
 		Settings: &engine.HTTPFrontendSettings{
@@ -38,99 +65,64 @@ func (c *Client) SyncFrontend(ingress *v1beta1.Ingress, backend *v1beta1.Ingress
 				MaxMemBodyBytes: int64(annotations.GetInt(ingress, annotations.MaxMemBodyBytes)),
 			},
 		},
-	}, time.Duration(0))
-}
-
-func (c *Client) DeleteFrontend(ns, name string) error {
-
-	frontends, err := c.Client.GetFrontends()
-	if err != nil {
-		return err
-	}
-
-	for _, frontend := range frontends {
-
-		if strings.HasPrefix(frontend.Id, strings.Join([]string{ns, name}, ".")) {
-
-			err = c.Client.DeleteFrontend(frontend.Key())
-			if err != nil {
-				return err
-			}
-		}
 	}
-
-	return nil
 }
 
-func (c *Client) SyncBackend(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend) error {
-
-	err := c.UpsertBackend(engine.Backend{
-		Id:   CreateID(ingress, backend),
+// CreateBackend returns the vulcand backend with the given ID. Its settings
+// are read from the annotations of the service, falling back to those of the
+// ingress. Either may be nil; backends shared between ingresses leave the
+// ingress out, so that their settings don't depend on which ingress was synced
+// last.
+func CreateBackend(id string, ingress *v1beta1.Ingress, service *v1.Service) engine.Backend {
+	lookup := backendAnnotations(ingress, service)
+	return engine.Backend{
+		Id:   id,
 		Type: engine.HTTP,
 		Settings: engine.HTTPBackendSettings{
 			Timeouts: engine.HTTPBackendTimeouts{
-				Read:         annotations.GetString(ingress, annotations.ReadTimeout),
-				Dial:         annotations.GetString(ingress, annotations.DialTimeout),
-				TLSHandshake: annotations.GetString(ingress, annotations.TLSHandshakeTimeout),
+				Read:         lookup(annotations.ReadTimeout),
+				Dial:         lookup(annotations.DialTimeout),
+				TLSHandshake: lookup(annotations.TLSHandshakeTimeout),
 			},
 			KeepAlive: engine.HTTPBackendKeepAlive{
-				Period:              annotations.GetString(ingress, annotations.KeepAlive),
-				MaxIdleConnsPerHost: annotations.GetInt(ingress, annotations.MaxIdleConnsPerHost),
+				Period:              lookup(annotations.KeepAlive),
+				MaxIdleConnsPerHost: annotations.Int(lookup(annotations.MaxIdleConnsPerHost)),
 			},
+			TLS: CreateBackendTLS(ingress, service),
 		},
-	})
-	if err != nil {
-		return err
 	}
-
-	return c.UpsertServer(
-		engine.BackendKey{Id: CreateID(ingress, backend)},
-		engine.Server{Id: CreateID(ingress, backend), URL: CreateURL(ingress, backend)},
-		time.Duration(0),
-	)
 }
 
-func (c *Client) DeleteBackend(ns, name string) error {
-	// As the backend's ID is made up from the ingress name and service name we
-	// list all the vulcan backends and check which ones start with the
-	// backend's ID.
-	backends, err := c.Client.GetBackends()
-	if err != nil {
-		return err
+// backendAnnotations returns a function looking up backend annotations on the
+// service first and the ingress second.
+func backendAnnotations(ingress *v1beta1.Ingress, service *v1.Service) func(string) string {
+	var serviceAnnotations, ingressAnnotations map[string]string
+	if service != nil {
+		serviceAnnotations = service.Annotations
 	}
+	if ingress != nil {
+		ingressAnnotations = ingress.Annotations
+	}
+	return func(a string) string {
+		return annotations.Lookup(a, serviceAnnotations, ingressAnnotations)
+	}
+}
 
-	for _, backend := range backends {
-		// If the backend matches the namespace and ingress name in prefix then
-		// we delete it.
-		if strings.HasPrefix(backend.Id, strings.Join([]string{ns, name}, ".")) {
-
-			// Also list all the servers that are registered with this backend
-			// and delete them
-			servers, err := c.Client.GetServers(backend.Key())
-			if err != nil {
-				return err
-			}
-
-			for _, server := range servers {
-				err := c.Client.DeleteServer(engine.ServerKey{
-					Id:         server.Id,
-					BackendKey: backend.Key(),
-				})
-				if err != nil {
-					return err
-				}
-			}
-
-			err = c.Client.DeleteBackend(backend.Key())
-			if err != nil {
-				return err
-			}
-		}
+func CreateHost(name string, keyPair *engine.KeyPair, ocsp engine.OCSPSettings) engine.Host {
+	return engine.Host{
+		Name: name,
+		Settings: engine.HostSettings{
+			KeyPair: keyPair,
+			OCSP:    ocsp,
+		},
 	}
-	return nil
 }
 
-func (c *Client) SyncMiddleware(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend) error {
+// CreateMiddlewares parses the middleware annotations of the ingress and
+// returns the middlewares to attach to the frontend. Middleware IDs are scoped
+// to their frontend, so the middleware type is used as ID.
+func (c *Client) CreateMiddlewares(ingress *v1beta1.Ingress, frontendKey engine.FrontendKey) ([]Middleware, error) {
+	var middlewares []Middleware
 	for key, value := range annotations.GetMiddleware(ingress) {
 
 		// Retrieve the middleware specification from the vulcand plugin
@@ -140,31 +132,250 @@ func (c *Client) SyncMiddleware(ingress *v1beta1.Ingress, backend *v1beta1.Ingre
 			// Parse the middleware configuration from a JSON payload.
 			m, err := spec.FromJSON([]byte(value))
 			if err != nil {
-				return err
+				return nil, fmt.Errorf("invalid %s middleware: %s", key, err)
 			}
-			// Now upsert the middleware to the vulcand API.
-			err = c.UpsertMiddleware(
-				engine.FrontendKey{
-					Id: CreateID(ingress, backend),
-				},
-				engine.Middleware{
-					Id:         CreateID(ingress, backend, key),
+			middlewares = append(middlewares, Middleware{
+				FrontendKey: frontendKey,
+				Middleware: engine.Middleware{
+					Id:         key,
 					Type:       key,
 					Middleware: m,
 				},
-				time.Duration(0))
-			if err != nil {
-				return err
-			}
+			})
+		}
+	}
+	return middlewares, nil
+}
+
+// UnknownMiddlewares returns the types of the middleware annotations of the
+// ingress which are not known to the vulcand plugin registry. These are
+// ignored by CreateMiddlewares.
+func (c *Client) UnknownMiddlewares(ingress *v1beta1.Ingress) []string {
+	var unknown []string
+	for key := range annotations.GetMiddleware(ingress) {
+		if c.Registry.GetSpec(key) == nil {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	return unknown
+}
+
+// SyncServers makes sure the servers registered with the backend are exactly
+// the ones given. Servers are upserted first and only then are stale servers
+// removed, so the backend is never left without servers during a sync. Stale
+// servers are deleted after the deletion delay rather than right away, unless
+// they are among the unready servers. Those belong to pods which are still
+// running, so there are no requests to finish and nothing should be routed to
+// them anymore.
+func (c *Client) SyncServers(backendKey engine.BackendKey, servers []engine.Server, unready []string) error {
+	return c.syncServers(backendKey, servers, unready, c.deletionDelay)
+}
+
+func (c *Client) syncServers(backendKey engine.BackendKey, servers []engine.Server, unready []string, deletionDelay time.Duration) error {
+
+	desired := make(map[string]bool, len(servers))
+	isUnready := make(map[string]bool, len(unready))
+	for _, id := range unready {
+		isUnready[id] = true
+	}
+
+	for _, server := range servers {
+		err := c.UpsertServer(backendKey, server, time.Duration(0))
+		if err != nil {
+			return err
+		}
+		desired[server.Id] = true
+	}
+
+	existing, err := c.GetServers(backendKey)
+	if err != nil {
+		return err
+	}
+
+	c.deletingMu.Lock()
+	defer c.deletingMu.Unlock()
+
+	// Servers which are desired again, or which vulcand already expired, are
+	// no longer pending deletion.
+	present := make(map[string]bool, len(existing))
+	for _, server := range existing {
+		present[server.Id] = true
+	}
+	for key := range c.deleting {
+		if key.BackendKey == backendKey && (desired[key.Id] || !present[key.Id]) {
+			delete(c.deleting, key)
 		}
 	}
+
+	for _, server := range existing {
+		if desired[server.Id] {
+			continue
+		}
+		delay := deletionDelay
+		if isUnready[server.Id] {
+			delay = 0
+		}
+		err := c.deleteLater(engine.ServerKey{
+			Id:         server.Id,
+			BackendKey: backendKey,
+		}, server, delay)
+		if err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
-func CreateID(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend, extra ...string) string {
+// deleteLater deletes the server once the deletion delay has passed. Until
+// then it is kept, and still routed to, with a TTL set to the delay, so that
+// vulcand deletes it even if the controller is restarted in the meantime. A
+// restarted controller doesn't know for how long a server has been pending
+// deletion already, so it delays the deletion again.
+// Must be called with deletingMu held.
+func (c *Client) deleteLater(key engine.ServerKey, server engine.Server, deletionDelay time.Duration) error {
+
+	if deletionDelay <= 0 {
+		delete(c.deleting, key)
+		return c.DeleteServer(key)
+	}
+
+	if until, ok := c.deleting[key]; ok {
+		if time.Now().Before(until) {
+			return nil
+		}
+		delete(c.deleting, key)
+		return ignoreNotFound(c.DeleteServer(key))
+	}
+
+	if err := c.UpsertServer(key.BackendKey, server, deletionDelay); err != nil {
+		return err
+	}
+	until := time.Now().Add(deletionDelay)
+	c.deleting[key] = until
+
+	if c.logger != nil {
+		c.logger.WithFields(logrus.Fields{
+			"backend": key.BackendKey.Id,
+			"server":  key.Id,
+			"url":     server.URL,
+			"until":   until.Format(time.RFC3339),
+		}).Info("Delaying server deletion")
+	}
+
+	return nil
+}
+
+// maxIDLength keeps IDs short enough to be comfortably used as etcd keys and
+// URL path segments by vulcand.
+const maxIDLength = 63
+
+// hashLength is the number of hex characters of the hash suffix of an ID.
+const hashLength = 10
+
+var invalidIDChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// CreateID builds a stable ID for a vulcand object from the given parts. The
+// ID consists of a readable prefix made up from the parts, followed by a hash
+// of all parts which keeps IDs unique even if the prefix had to be truncated
+// or different parts sanitize to the same prefix.
+//
+// IDs only contain lower case letters, digits and dashes. vulcand uses IDs as
+// etcd keys and URL path segments, so slashes are not allowed, and splits
+// server keys on the first dot, so backend IDs can't contain dots either.
+func CreateID(parts ...string) string {
+
+	hash := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	suffix := hex.EncodeToString(hash[:])[:hashLength]
+
+	var readable []string
+	for _, part := range parts {
+		if part = strings.Trim(invalidIDChars.ReplaceAllString(strings.ToLower(part), "-"), "-"); part != "" {
+			readable = append(readable, part)
+		}
+	}
+
+	prefix := strings.Join(readable, "-")
+	if max := maxIDLength - hashLength - 1; len(prefix) > max {
+		prefix = strings.TrimRight(prefix[:max], "-")
+	}
+	if prefix == "" {
+		return suffix
+	}
+	return prefix + "-" + suffix
+}
+
+// CreateFrontendID returns the ID of the frontend which routes the host and
+// path of the ingress.
+func CreateFrontendID(ingress *v1beta1.Ingress, host, path string) string {
+	return CreateID(ingress.Namespace, ingress.Name, host, path)
+}
+
+// CreateBackendID returns the ID of the backend which holds the servers of the
+// service port referenced by the ingress.
+func CreateBackendID(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend) string {
+	return CreateID(ingress.Namespace, ingress.Name, backend.ServiceName, backend.ServicePort.String())
+}
+
+// CreateSharedBackendID returns the ID of the backend which holds the servers
+// of the service port, shared by every ingress in the namespace routing to it.
+// The port is resolved against the service, which may be nil, so ingresses
+// referring to it by name and by number share the same backend.
+func CreateSharedBackendID(namespace string, backend *v1beta1.IngressBackend, service *v1.Service) string {
+	port := backend.ServicePort.String()
+	if service != nil {
+		if p, ok := FindServicePort(service, backend.ServicePort); ok {
+			port = strconv.Itoa(int(p.Port))
+		}
+	}
+	return CreateID(namespace, backend.ServiceName, port)
+}
+
+// CreateLegacyID returns the IDs used by earlier versions of the controller,
+// which were only made up from the namespace, ingress and service name. They
+// are only used to migrate objects to the current IDs.
+func CreateLegacyID(ingress *v1beta1.Ingress, backend *v1beta1.IngressBackend, extra ...string) string {
 	return strings.Join(append([]string{
 		ingress.Namespace,
 		ingress.Name,
 		backend.ServiceName},
 		extra...), ".")
 }
+
+// CreateLegacyState returns the keys of the objects earlier versions of the
+// controller created for the ingress, so they can be migrated.
+func CreateLegacyState(ingress *v1beta1.Ingress) *State {
+
+	state := &State{}
+
+	add := func(backend *v1beta1.IngressBackend, middlewares bool) {
+		id := CreateLegacyID(ingress, backend)
+		state.AddBackend(Backend{Backend: engine.Backend{Id: id}})
+		state.AddFrontend(engine.Frontend{Id: id})
+		if !middlewares {
+			return
+		}
+		for key := range annotations.GetMiddleware(ingress) {
+			state.AddMiddleware(Middleware{
+				FrontendKey: engine.FrontendKey{Id: id},
+				Middleware:  engine.Middleware{Id: CreateLegacyID(ingress, backend, key)},
+			})
+		}
+	}
+
+	if backend := ingress.Spec.Backend; backend != nil {
+		add(backend, false)
+	}
+
+	for _, rule := range ingress.Spec.Rules {
+		if rule.HTTP == nil {
+			continue
+		}
+		for i := range rule.HTTP.Paths {
+			add(&rule.HTTP.Paths[i].Backend, true)
+		}
+	}
+
+	return state
+}
@@ -1,13 +1,177 @@
 package vulcan
 
 import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
 	"testing"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/api/extensions/v1beta1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vulcand/vulcand/engine"
 )
 
+var validID = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]*[a-z0-9])?$`)
+
 func TestCreateID(t *testing.T) {
+	for expected, parts := range map[string][]string{
+		"namespace-ingress-example-com-foo": {"namespace", "ingress", "example.com", "/foo"},
+		"namespace-ingress-v2":              {"namespace", "ingress.v2", "", ""},
+		"namespace-ingress-foo-bar":         {"Namespace", "ingress", "/foo/(bar)*"},
+	} {
+		id := CreateID(parts...)
+		if !strings.HasPrefix(id, expected+"-") || len(id) != len(expected)+hashLength+1 {
+			t.Errorf("Unexpected ID %q from parts %q, expected prefix %q", id, parts, expected)
+		}
+		if !validID.MatchString(id) {
+			t.Errorf("Invalid ID %q from parts %q", id, parts)
+		}
+		if id != CreateID(parts...) {
+			t.Errorf("Unstable ID %q from parts %q", id, parts)
+		}
+	}
+}
+
+func TestCreateIDLength(t *testing.T) {
+	long := strings.Repeat("a", 253)
+	id := CreateID("namespace", long, "example.com", "/foo")
+	if len(id) > maxIDLength {
+		t.Errorf("ID %q exceeds %d characters", id, maxIDLength)
+	}
+	if !validID.MatchString(id) {
+		t.Errorf("Invalid ID %q", id)
+	}
+	if id == CreateID("namespace", long, "example.com", "/bar") {
+		t.Errorf("Unexpected truncated ID collision %q", id)
+	}
+}
+
+func TestCreateFrontendID(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+		},
+	}
+
+	ids := make(map[string]string)
+
+	for _, test := range []struct {
+		host string
+		path string
+	}{
+		{"", ""},
+		{"example.com", ""},
+		{"example.com", "/foo"},
+		{"example.com", "/bar"},
+		{"www.example.com", "/foo"},
+		{"example-com", "/foo"},
+		{"", "/foo"},
+	} {
+		id := CreateFrontendID(ingress, test.host, test.path)
+		if other, ok := ids[id]; ok {
+			t.Errorf("Colliding ID %q for host %q and path %q, already used by %s", id, test.host, test.path, other)
+		}
+		ids[id] = test.host + test.path
+	}
+}
+
+func TestCreateBackendID(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+		},
+	}
+
+	http := CreateBackendID(ingress, &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromInt(80)})
+	https := CreateBackendID(ingress, &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromInt(443)})
+	named := CreateBackendID(ingress, &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromString("http")})
+
+	if http == https || http == named || https == named {
+		t.Errorf("Unexpected colliding backend IDs %q, %q and %q", http, https, named)
+	}
+	if strings.Contains(http, ".") {
+		t.Errorf("Backend ID %q must not contain dots", http)
+	}
+}
+
+func TestCreateBackend(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      "ingress",
+			Namespace: "namespace",
+			Annotations: map[string]string{
+				"ingress.kubernetes.io/read-timeout":                  "5s",
+				"ingress.kubernetes.io/dial-timeout":                  "7s",
+				"ingress.kubernetes.io/max-idle-connections-per-host": "4",
+			},
+		},
+	}
+	service := &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{
+				"ingress.kubernetes.io/read-timeout":                  "30s",
+				"ingress.kubernetes.io/max-idle-connections-per-host": "12",
+			},
+		},
+	}
+	id := CreateBackendID(ingress, &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromInt(80)})
+
+	settings := CreateBackend(id, ingress, service).Settings.(engine.HTTPBackendSettings)
+	if settings.Timeouts.Read != "30s" || settings.Timeouts.Dial != "7s" || settings.KeepAlive.MaxIdleConnsPerHost != 12 {
+		t.Errorf("Expected service annotations to take precedence, got %+v", settings)
+	}
+
+	settings = CreateBackend(id, ingress, nil).Settings.(engine.HTTPBackendSettings)
+	if settings.Timeouts.Read != "5s" || settings.KeepAlive.MaxIdleConnsPerHost != 4 {
+		t.Errorf("Expected ingress annotations without a service, got %+v", settings)
+	}
+
+	settings = CreateBackend(id, nil, service).Settings.(engine.HTTPBackendSettings)
+	if settings.Timeouts.Read != "30s" || settings.Timeouts.Dial != "" {
+		t.Errorf("Expected service annotations only without an ingress, got %+v", settings)
+	}
+}
+
+func TestCreateSharedBackendID(t *testing.T) {
+	backend := &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromInt(80)}
+
+	shared := CreateSharedBackendID("namespace", backend, nil)
+	if other := CreateSharedBackendID("other", backend, nil); shared == other {
+		t.Errorf("Unexpected colliding shared backend ID %q across namespaces", shared)
+	}
+	service := &corev1.Service{
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{{Name: "http", Port: 80}},
+		},
+	}
+	named := &v1beta1.IngressBackend{ServiceName: "foo", ServicePort: intstr.FromString("http")}
+	if id := CreateSharedBackendID("namespace", named, service); id != shared {
+		t.Errorf("Expected the named port to share backend %q, got %q", shared, id)
+	}
+	if id := CreateSharedBackendID("namespace", named, nil); id == shared {
+		t.Errorf("Unexpected shared backend ID %q for an unresolved named port", id)
+	}
+	for _, name := range []string{"a", "b"} {
+		ingress := &v1beta1.Ingress{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "namespace"}}
+		if id := CreateBackendID(ingress, backend); id == shared {
+			t.Errorf("Unexpected shared backend ID %q for ingress %s", id, name)
+		}
+	}
+}
+
+func TestCreateLegacyID(t *testing.T) {
 	for expected, test := range map[string]struct {
 		ingress *v1beta1.Ingress
 		backend *v1beta1.IngressBackend
@@ -26,7 +190,7 @@ func TestCreateID(t *testing.T) {
 			[]string{},
 		},
 	} {
-		id := CreateID(test.ingress, test.backend, test.extra...)
+		id := CreateLegacyID(test.ingress, test.backend, test.extra...)
 		if id != expected {
 			t.Errorf("Unexpected route %q from ingress %q backend %q and extra %q",
 				id,
@@ -36,3 +200,152 @@ func TestCreateID(t *testing.T) {
 		}
 	}
 }
+
+func TestUnknownMiddlewares(t *testing.T) {
+	ingress := &v1beta1.Ingress{
+		ObjectMeta: v1.ObjectMeta{
+			Annotations: map[string]string{
+				"ingress.kubernetes.io/middleware.ratelimit": "{}",
+				"ingress.kubernetes.io/middleware.typo":      "{}",
+				"ingress.kubernetes.io/read-timeout":         "5s",
+			},
+		},
+	}
+
+	unknown := New("http://localhost:8182", 0, nil).UnknownMiddlewares(ingress)
+	if !reflect.DeepEqual(unknown, []string{"typo"}) {
+		t.Errorf("Unexpected unknown middlewares %q", unknown)
+	}
+}
+
+// fakeServers serves the servers of a single backend the way the vulcand API
+// does, remembering the TTL each server was last upserted with.
+type fakeServers struct {
+	servers map[string]engine.Server
+	ttls    map[string]string
+}
+
+func (f *fakeServers) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case "GET":
+		var servers []engine.Server
+		for _, server := range f.servers {
+			servers = append(servers, server)
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"Servers": servers})
+	case "POST":
+		var pack struct {
+			Server engine.Server
+			TTL    string
+		}
+		json.NewDecoder(r.Body).Decode(&pack)
+		f.servers[pack.Server.Id] = pack.Server
+		f.ttls[pack.Server.Id] = pack.TTL
+		w.Write([]byte("{}"))
+	case "DELETE":
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+		delete(f.servers, id)
+		delete(f.ttls, id)
+		w.Write([]byte(`{"message":"deleted"}`))
+	}
+}
+
+func (f *fakeServers) ids() []string {
+	var ids []string
+	for id := range f.servers {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestSyncServersDeletionDelay(t *testing.T) {
+	fake := &fakeServers{servers: map[string]engine.Server{}, ttls: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	c := New(server.URL, time.Minute, logger)
+	backendKey := engine.BackendKey{Id: "backend"}
+	foo := engine.Server{Id: "foo", URL: "http://10.0.0.1:8080"}
+	bar := engine.Server{Id: "bar", URL: "http://10.0.0.2:8080"}
+
+	if err := c.SyncServers(backendKey, []engine.Server{foo, bar}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncServers(backendKey, []engine.Server{foo}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ids := fake.ids(); !reflect.DeepEqual(ids, []string{"bar", "foo"}) {
+		t.Fatalf("Expected bar to be kept until the deletion delay passed, got %v", ids)
+	}
+	if fake.ttls["bar"] != "1m0s" || fake.ttls["foo"] != "0s" {
+		t.Errorf("Unexpected TTLs %v", fake.ttls)
+	}
+
+	// Syncing again doesn't extend the deletion delay.
+	fake.ttls["bar"] = ""
+	if err := c.SyncServers(backendKey, []engine.Server{foo}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fake.ttls["bar"] != "" {
+		t.Errorf("Expected server pending deletion not to be upserted again, got TTL %q", fake.ttls["bar"])
+	}
+
+	// Once the deletion delay passed the server is deleted.
+	c.deleting[engine.ServerKey{Id: "bar", BackendKey: backendKey}] = time.Now().Add(-time.Second)
+	if err := c.SyncServers(backendKey, []engine.Server{foo}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if ids := fake.ids(); !reflect.DeepEqual(ids, []string{"foo"}) {
+		t.Errorf("Expected bar to be deleted after the deletion delay, got %v", ids)
+	}
+
+	// Servers of a deleted backend are removed right away.
+	if err := c.Delete(&State{Backends: []Backend{{Backend: engine.Backend{Id: "backend"}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.servers) != 0 {
+		t.Errorf("Expected servers to be deleted with their backend, got %v", fake.ids())
+	}
+}
+
+func TestSyncServersUnready(t *testing.T) {
+	fake := &fakeServers{servers: map[string]engine.Server{}, ttls: map[string]string{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	logger := logrus.New()
+	logger.Out = ioutil.Discard
+
+	c := New(server.URL, time.Minute, logger)
+	backendKey := engine.BackendKey{Id: "backend"}
+	foo := engine.Server{Id: "foo", URL: "http://10.0.0.1:8080"}
+	bar := engine.Server{Id: "bar", URL: "http://10.0.0.2:8080"}
+
+	if err := c.SyncServers(backendKey, []engine.Server{foo, bar}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// Servers of pods which turned unready are deleted right away.
+	if err := c.SyncServers(backendKey, []engine.Server{foo}, []string{"bar"}); err != nil {
+		t.Fatal(err)
+	}
+	if ids := fake.ids(); !reflect.DeepEqual(ids, []string{"foo"}) {
+		t.Errorf("Expected unready bar to be deleted right away, got %v", ids)
+	}
+
+	// A server pending deletion which is deleted right away is forgotten, so
+	// it is delayed again when it goes stale next time.
+	if err := c.SyncServers(backendKey, []engine.Server{bar}, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SyncServers(backendKey, []engine.Server{bar}, []string{"foo"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := c.deleting[engine.ServerKey{Id: "foo", BackendKey: backendKey}]; ok {
+		t.Error("Expected foo to be forgotten once deleted")
+	}
+}